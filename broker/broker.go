@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"log"
 	"math"
 	"net"
 	"net/rpc"
@@ -13,16 +15,36 @@ import (
 )
 
 var (
-	workers  = make([]*rpc.Client, 0)
-	workersM sync.Mutex
+	workers = make([]*rpc.Client, 0)
+	// addresses holds the address each of the workers subscribed from.
+	addresses = make([]string, 0)
+	workersM  sync.Mutex
 
 	world  [][]byte
 	turns  int
+	hist   *history
 	worldM sync.Mutex
 
+	statistics []stubs.TurnStatistics
+
+	detector     *stability
+	stableTurns  int
+	stablePeriod int
+
 	stopped  = false
 	stoppedM sync.Mutex
 
+	// turnRate is the most turns run per second, or 0 to run as fast as possible. Changes to it are signalled on rateChanges.
+	turnRate    int
+	turnRateM   sync.Mutex
+	rateChanges = make(chan bool, 1)
+
+	edits      []stubs.EditCellsRequest
+	editsM     sync.Mutex
+	amendments int
+	// starting is set between PreBreak and BreakWorld, while the history still belongs to the previous run.
+	starting bool
+
 	interrupts = make(chan bool)
 	closes     = make(chan bool)
 )
@@ -82,6 +104,87 @@ func countAliveCells() int {
 	return n
 }
 
+// applyEdits applies the queued edits to the world at a turn boundary, all at once.
+// It returns the change in population they made.
+func applyEdits(width, height int) (edited int) {
+	editsM.Lock()
+	pending := edits
+	edits = nil
+	editsM.Unlock()
+	if len(pending) == 0 {
+		return 0
+	}
+
+	worldM.Lock()
+	flips := make([]uint32, 0)
+	set := func(x, y int, val byte) {
+		x = (x%width + width) % width
+		y = (y%height + height) % height
+		if world[y][x] != val {
+			world[y][x] = val
+			flips = append(flips, uint32(y*width+x))
+			if val == 255 {
+				edited++
+			} else {
+				edited--
+			}
+		}
+	}
+	for _, edit := range pending {
+		for _, cell := range edit.Set {
+			set(cell.X, cell.Y, 255)
+		}
+		for _, cell := range edit.Clear {
+			set(cell.X, cell.Y, 0)
+		}
+		for y := range edit.Pattern {
+			for x := range edit.Pattern[y] {
+				set(edit.Offset.X+x, edit.Offset.Y+y, edit.Pattern[y][x])
+			}
+		}
+	}
+	hist.amend(flips)
+	if len(flips) > 0 {
+		amendments++
+		detector.reset(world, turns)
+		stablePeriod = 0
+	}
+	worldM.Unlock()
+	return edited
+}
+
+// pace waits until the next turn is due at the turn rate, reporting false if the run is interrupted meanwhile.
+// due is when the next turn is due, and is zero while running as fast as possible.
+func pace(due *time.Time) bool {
+	for {
+		turnRateM.Lock()
+		rate := turnRate
+		turnRateM.Unlock()
+		if rate <= 0 {
+			*due = time.Time{}
+			return true
+		}
+		interval := time.Second / time.Duration(rate)
+		now := time.Now()
+		// A run that has just been limited, or has fallen behind, starts keeping time from now rather than catching up.
+		if due.IsZero() || now.Sub(*due) > interval {
+			*due = now
+		}
+		wait := due.Sub(now)
+		if wait <= 0 {
+			*due = due.Add(interval)
+			return true
+		}
+		select {
+		case <-interrupts:
+			return false
+		case <-rateChanges:
+			*due = time.Time{}
+		case <-time.After(wait):
+		}
+	}
+}
+
 type Broker struct{}
 
 func (b *Broker) Subscribe(req stubs.SubscribeRequest, _ *stubs.SubscribeResponse) (err error) {
@@ -92,14 +195,19 @@ func (b *Broker) Subscribe(req stubs.SubscribeRequest, _ *stubs.SubscribeRespons
 
 	workersM.Lock()
 	workers = append(workers, client)
+	addresses = append(addresses, req.Address)
 	workersM.Unlock()
 	return
 }
 
-func (b *Broker) PreBreak(_ stubs.PreBreakRequest, _ *stubs.PreBreakResponse) (err error) {
+func (b *Broker) PreBreak(_ stubs.PreBreakRequest, res *stubs.PreBreakResponse) (err error) {
 	stoppedM.Lock()
 	stopped = false
 	stoppedM.Unlock()
+	worldM.Lock()
+	starting = true
+	res.Amendments = amendments
+	worldM.Unlock()
 	return
 }
 
@@ -110,22 +218,49 @@ func (b *Broker) BreakWorld(req stubs.BreakWorldRequest, res *stubs.BreakWorldRe
 	if req.Threads < threads {
 		threads = req.Threads
 	}
+	workersM.Lock()
+	res.Workers = append([]string(nil), addresses[:threads]...)
+	workersM.Unlock()
 
 	worldM.Lock()
+	// Keep the recorded history if the client continues from a generation in it,
+	// everything after that generation is forgotten as the run diverges from there.
+	if hist.matches(req.World, req.ImageWidth, req.ImageHeight, req.CompletedTurns) {
+		hist.truncate(req.CompletedTurns)
+	} else {
+		hist.reset(req.World, req.ImageWidth, req.ImageHeight, req.CompletedTurns)
+		// Edits queued against a previous run do not apply to this one.
+		editsM.Lock()
+		edits = nil
+		editsM.Unlock()
+	}
 	world = req.World
-	turns = 0
+	turns = req.CompletedTurns
+	statistics = nil
+	detector.reset(world, turns)
+	stablePeriod = 0
+	starting = false
 	worldM.Unlock()
+	turnRateM.Lock()
+	turnRate = req.TurnRate
+	turnRateM.Unlock()
+	target := req.CompletedTurns + req.Turns
 
 	worldSlices, heights := calculateWorldSlices(threads, req.ImageHeight)
 
 	responses := make([]*stubs.RunWorldResponse, threads)
 	done := make(chan *rpc.Call, threads)
+	var due time.Time
 out:
-	for turns < req.Turns {
+	for turns < target {
 		select {
 		case <-interrupts:
 			break out
 		default:
+			if !pace(&due) {
+				break out
+			}
+			edited := applyEdits(req.ImageWidth, req.ImageHeight)
 			for i := 0; i < threads; i++ {
 				responses[i] = new(stubs.RunWorldResponse)
 				request := stubs.RunWorldRequest{
@@ -141,16 +276,77 @@ out:
 			}
 
 			worldM.Lock()
+			flips := make([]uint32, 0)
+			stats := stubs.TurnStatistics{
+				Edited:      edited,
+				TopLeft:     util.Cell{X: req.ImageWidth, Y: req.ImageHeight},
+				BottomRight: util.Cell{X: -1, Y: -1},
+			}
 			h := 0
 			for i, response := range responses {
 				for j := 0; j < heights[i]; j++ {
 					for k := 0; k < req.ImageWidth; k++ {
-						world[j+h][k] = response.WorldSlice[j][k]
+						cell := response.WorldSlice[j][k]
+						if world[j+h][k] != cell {
+							flips = append(flips, uint32((j+h)*req.ImageWidth+k))
+							if cell == 255 {
+								stats.Births++
+							} else {
+								stats.Deaths++
+							}
+						}
+						if req.Statistics && cell == 255 {
+							stats.Population++
+							if k < stats.TopLeft.X {
+								stats.TopLeft.X = k
+							}
+							if k > stats.BottomRight.X {
+								stats.BottomRight.X = k
+							}
+							if j+h < stats.TopLeft.Y {
+								stats.TopLeft.Y = j + h
+							}
+							stats.BottomRight.Y = j + h
+						}
+						world[j+h][k] = cell
 					}
 				}
 				h += heights[i]
 			}
 			turns++
+			if req.Statistics {
+				stats.CompletedTurns = turns
+				if stats.Population == 0 {
+					stats.TopLeft, stats.BottomRight = util.Cell{}, util.Cell{}
+				}
+				statistics = append(statistics, stats)
+			}
+			hist.push(world, turns, flips)
+			if period, ok := detector.push(world, turns); ok {
+				if stablePeriod == 0 {
+					stableTurns = turns
+					stablePeriod = period
+				}
+				// A periodic world only needs the turns left over after the last whole period.
+				// The hashes only suggest the period, so the world must match the generation a period back
+				// before any turns are skipped, and the turns are computed as usual if it does not.
+				if req.FastForward && target-turns >= period && hist.matches(world, req.ImageWidth, req.ImageHeight, turns-period) {
+					skipped := (target - turns) / period * period
+					turns += skipped
+					// The skipped turns are marked by a single row of statistics, as the world is where it was.
+					if req.Statistics {
+						statistics = append(statistics, stubs.TurnStatistics{
+							CompletedTurns: turns,
+							Skipped:        skipped,
+							Population:     stats.Population,
+							TopLeft:        stats.TopLeft,
+							BottomRight:    stats.BottomRight,
+						})
+					}
+					hist.keyframe(world, turns)
+					detector.reset(world, turns)
+				}
+			}
 			worldM.Unlock()
 		}
 	}
@@ -158,8 +354,10 @@ out:
 	res.World = world
 	res.CompletedTurns = turns
 	res.AliveCells = calculateAliveCells()
+	res.StableTurns = stableTurns
+	res.Period = stablePeriod
 
-	if turns == req.Turns {
+	if turns == target {
 		stoppedM.Lock()
 		if stopped {
 			stoppedM.Unlock()
@@ -176,6 +374,8 @@ func (b *Broker) CountAlive(_ *stubs.CountAliveRequest, response *stubs.CountAli
 	worldM.Lock()
 	response.CompletedTurns = turns
 	response.CellsCount = countAliveCells()
+	response.StableTurns = stableTurns
+	response.Period = stablePeriod
 	worldM.Unlock()
 	return
 }
@@ -188,6 +388,90 @@ func (b *Broker) CurrentState(_ *stubs.CurrentStateRequest, response *stubs.Curr
 	return
 }
 
+func (b *Broker) Seek(req stubs.SeekRequest, res *stubs.SeekResponse) (err error) {
+	worldM.Lock()
+	if len(hist.segments) == 0 {
+		worldM.Unlock()
+		return errors.New("no generations have been recorded yet")
+	}
+	res.CompletedTurns = hist.clamp(req.Turn)
+	res.World = hist.at(res.CompletedTurns)
+	worldM.Unlock()
+	return
+}
+
+func (b *Broker) EditCells(req stubs.EditCellsRequest, _ *stubs.EditCellsResponse) (err error) {
+	editsM.Lock()
+	edits = append(edits, req)
+	editsM.Unlock()
+	return
+}
+
+func (b *Broker) Statistics(_ stubs.StatisticsRequest, res *stubs.StatisticsResponse) (err error) {
+	worldM.Lock()
+	res.Turns = statistics
+	statistics = nil
+	worldM.Unlock()
+	return
+}
+
+func (b *Broker) Generations(req stubs.GenerationsRequest, res *stubs.GenerationsResponse) (err error) {
+	worldM.Lock()
+	defer worldM.Unlock()
+	if starting {
+		res.Amendments = req.Amendments
+		return
+	}
+	if len(hist.segments) == 0 {
+		return errors.New("no generations have been recorded yet")
+	}
+	res.Amendments = amendments
+
+	latest := hist.latest()
+	if req.Amendments != amendments || req.From > latest || !hist.contains(req.From) {
+		res.Generations = []stubs.Generation{{
+			CompletedTurns: latest,
+			Keyframe:       util.PackWorld(hist.at(latest), hist.width, hist.height),
+		}}
+		return
+	}
+	for _, s := range hist.segments {
+		start := s.turn
+		if start <= req.From {
+			start = req.From + 1
+		}
+		for turn := start; turn <= s.turn+len(s.diffs); turn++ {
+			if len(res.Generations) == req.Limit {
+				return
+			}
+			generation := stubs.Generation{CompletedTurns: turn}
+			if turn == s.turn {
+				// Edits amend keyframes in place, so send a copy.
+				generation.Keyframe = append([]byte(nil), s.keyframe...)
+			} else {
+				generation.Flips = s.diffs[turn-s.turn-1]
+			}
+			res.Generations = append(res.Generations, generation)
+		}
+	}
+	return
+}
+
+func (b *Broker) SetTurnRate(req stubs.TurnRateRequest, res *stubs.TurnRateResponse) (err error) {
+	turnRateM.Lock()
+	turnRate = req.TurnRate
+	turnRateM.Unlock()
+	worldM.Lock()
+	res.CompletedTurns = turns
+	worldM.Unlock()
+	// Wake a run waiting for its next turn, so that it does not wait out the old rate.
+	select {
+	case rateChanges <- true:
+	default:
+	}
+	return
+}
+
 func (b *Broker) Pause(_ *stubs.PauseRequest, _ *stubs.PauseResponse) (err error) {
 	stoppedM.Lock()
 	if !stopped {
@@ -207,12 +491,39 @@ func (b *Broker) Close(_ *stubs.PauseRequest, _ *stubs.PauseResponse) (err error
 
 func main() {
 	pAddr := flag.String("port", "8030", "Port to listen on")
+	pKeyframe := flag.Int("keyframe", 100, "Number of turns between keyframes in the turn history")
+	pHistory := flag.Int("history", 256, "Memory budget of the turn history in MB")
+	pPeriod := flag.Int("period", 256, "Longest period to detect when the world becomes periodic")
+	pSave := flag.String("save", "", "Snapshot file to save the current world to when the broker is closed")
+	pLoad := flag.String("load", "", "Snapshot file to load the current world from when the broker starts")
 	flag.Parse()
+	hist = newHistory(*pKeyframe, *pHistory<<20)
+	detector = newStability(*pPeriod)
+	// A loaded world is where a run stopped, so a client resuming from the same snapshot continues its history.
+	if *pLoad != "" {
+		s, err := util.LoadSnapshot(*pLoad)
+		if err != nil {
+			log.Fatalf("[Broker] %v Failed to load %v: %v", util.Red("ERROR"), *pLoad, err)
+		}
+		world, turns = s.World, s.Turn
+		hist.reset(world, s.Width, s.Height, turns)
+		detector.reset(world, turns)
+		log.Printf("[Broker] Loaded a %vx%v world at turn %v from %v", s.Width, s.Height, s.Turn, *pLoad)
+	}
 	rpc.Register(&Broker{})
 	listener, _ := net.Listen("tcp", ":"+*pAddr)
 	go rpc.Accept(listener)
 
 	<-closes
+	if *pSave != "" {
+		worldM.Lock()
+		if world != nil {
+			if err := util.SaveSnapshot(*pSave, util.NewSnapshot(world, turns)); err != nil {
+				log.Printf("[Broker] %v Failed to save %v: %v", util.Red("ERROR"), *pSave, err)
+			}
+		}
+		worldM.Unlock()
+	}
 	req := stubs.CloseRequest{}
 	res := new(stubs.CloseResponse)
 	for _, worker := range workers {
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// history keeps a compressed record of past generations so that any of them can be restored.
+// Every interval turns the whole world is stored bit-packed as a keyframe, and every turn in
+// between only stores the indices of the cells that flipped. Whole segments are dropped from the front
+// once the history grows beyond its memory budget.
+type history struct {
+	width, height int
+	interval      int
+	budget        int
+	size          int
+	segments      []*segment
+}
+
+// segment is a keyframe followed by the flips leading to each of the next turns.
+type segment struct {
+	turn     int
+	keyframe []byte
+	diffs    [][]uint32
+	size     int
+}
+
+func newHistory(interval, budget int) *history {
+	if interval < 1 {
+		interval = 1
+	}
+	return &history{
+		interval: interval,
+		budget:   budget,
+	}
+}
+
+// reset discards the whole history and starts again from the given world.
+func (h *history) reset(world [][]byte, width, height, turn int) {
+	h.width = width
+	h.height = height
+	h.size = 0
+	h.segments = nil
+	h.keyframe(world, turn)
+}
+
+func (h *history) keyframe(world [][]byte, turn int) {
+	s := &segment{
+		turn:     turn,
+		keyframe: util.PackWorld(world, h.width, h.height),
+	}
+	s.size = len(s.keyframe)
+	h.segments = append(h.segments, s)
+	h.size += s.size
+	h.evict()
+}
+
+// evict drops the oldest segments until the history fits in its budget again.
+// The newest segment is always kept.
+func (h *history) evict() {
+	for h.size > h.budget && len(h.segments) > 1 {
+		h.size -= h.segments[0].size
+		h.segments[0] = nil
+		h.segments = h.segments[1:]
+	}
+}
+
+// push records the flips that produced world, which is the generation after turn-1.
+func (h *history) push(world [][]byte, turn int, flips []uint32) {
+	last := h.segments[len(h.segments)-1]
+	if turn-last.turn >= h.interval {
+		h.keyframe(world, turn)
+		return
+	}
+	last.diffs = append(last.diffs, flips)
+	last.size += 4 * len(flips)
+	h.size += 4 * len(flips)
+	h.evict()
+}
+
+// amend records flips applied to the latest generation in place, e.g. by an edit.
+func (h *history) amend(flips []uint32) {
+	last := h.segments[len(h.segments)-1]
+	if len(last.diffs) == 0 {
+		for _, i := range flips {
+			last.keyframe[i/8] ^= 1 << (i % 8)
+		}
+		return
+	}
+	n := len(last.diffs) - 1
+	last.diffs[n] = append(last.diffs[n], flips...)
+	last.size += 4 * len(flips)
+	h.size += 4 * len(flips)
+}
+
+func (h *history) oldest() int {
+	return h.segments[0].turn
+}
+
+func (h *history) latest() int {
+	last := h.segments[len(h.segments)-1]
+	return last.turn + len(last.diffs)
+}
+
+// find returns the segment holding the generation at turn, or nil if it is not recorded.
+// Segments are not necessarily contiguous, as turns skipped by fast-forwarding are never recorded.
+func (h *history) find(turn int) *segment {
+	for i := len(h.segments) - 1; i >= 0; i-- {
+		s := h.segments[i]
+		if s.turn <= turn {
+			if turn <= s.turn+len(s.diffs) {
+				return s
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// clamp returns the restorable turn closest to turn.
+func (h *history) clamp(turn int) int {
+	if h.find(turn) != nil {
+		return turn
+	}
+	closest := h.oldest()
+	for _, s := range h.segments {
+		for _, t := range []int{s.turn, s.turn + len(s.diffs)} {
+			if abs(t-turn) < abs(closest-turn) {
+				closest = t
+			}
+		}
+	}
+	return closest
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// contains reports whether the generation at turn can still be restored.
+func (h *history) contains(turn int) bool {
+	return h.find(turn) != nil
+}
+
+// matches reports whether world is the recorded generation at turn.
+func (h *history) matches(world [][]byte, width, height, turn int) bool {
+	if width != h.width || height != h.height || !h.contains(turn) {
+		return false
+	}
+	past := h.at(turn)
+	for i := range past {
+		for j := range past[i] {
+			if past[i][j] != world[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// at restores the generation at turn, which must be contained in the history.
+func (h *history) at(turn int) [][]byte {
+	s := h.find(turn)
+	world := util.UnpackWorld(s.keyframe, h.width, h.height)
+	for _, flips := range s.diffs[:turn-s.turn] {
+		for _, i := range flips {
+			world[int(i)/h.width][int(i)%h.width] ^= 255
+		}
+	}
+	return world
+}
+
+// truncate forgets every generation after turn, so that the run can continue from it.
+func (h *history) truncate(turn int) {
+	for i, s := range h.segments {
+		if s.turn > turn {
+			for _, dropped := range h.segments[i:] {
+				h.size -= dropped.size
+			}
+			h.segments = h.segments[:i]
+			break
+		}
+	}
+	last := h.segments[len(h.segments)-1]
+	for _, flips := range last.diffs[turn-last.turn:] {
+		last.size -= 4 * len(flips)
+		h.size -= 4 * len(flips)
+	}
+	last.diffs = last.diffs[:turn-last.turn]
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"hash/fnv"
+)
+
+// stability detects when the world starts repeating itself by hashing every generation.
+// A repetition with period P is only reported once it has held for a whole period.
+// Hashes can still collide, so turns are only skipped for a period once the worlds themselves have been compared.
+type stability struct {
+	hashes []uint64
+	first  int
+	period int
+	since  int
+}
+
+func newStability(maxPeriod int) *stability {
+	if maxPeriod < 1 {
+		maxPeriod = 1
+	}
+	return &stability{hashes: make([]uint64, maxPeriod+1)}
+}
+
+func hashWorld(world [][]byte) uint64 {
+	h := fnv.New64a()
+	for _, row := range world {
+		_, _ = h.Write(row)
+	}
+	return h.Sum64()
+}
+
+// reset forgets all recorded generations and starts again from world.
+func (s *stability) reset(world [][]byte, turn int) {
+	s.first = turn
+	s.period = 0
+	s.since = 0
+	s.push(world, turn)
+}
+
+func (s *stability) hash(turn int) uint64 {
+	return s.hashes[turn%len(s.hashes)]
+}
+
+// push records the generation at turn and reports the period once the world is known to repeat.
+func (s *stability) push(world [][]byte, turn int) (int, bool) {
+	s.hashes[turn%len(s.hashes)] = hashWorld(world)
+
+	if s.period != 0 {
+		if s.hash(turn) != s.hash(turn-s.period) {
+			s.period = 0
+		} else if turn-s.since >= s.period {
+			return s.period, true
+		}
+	}
+	if s.period == 0 {
+		for period := 1; period < len(s.hashes) && turn-period >= s.first; period++ {
+			if s.hash(turn) == s.hash(turn-period) {
+				s.period = period
+				s.since = turn
+				break
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,146 @@
+package gol
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// autosaver saves snapshots of the followed generations according to the snapshot policy in Params,
+// every SnapshotEvery turns, every SnapshotInterval and at each of SnapshotTurns.
+// Snapshots are written by a goroutine of their own, so following the broker never waits for the disk.
+type autosaver struct {
+	every    int
+	interval time.Duration
+	turns    map[int]bool
+	last     time.Time
+	// offered is the turn of the last generation offered, from which the turns skipped over are found.
+	offered int
+
+	keep, keepEvery int
+	saved           []savedSnapshot
+
+	queue chan util.Snapshot
+	done  chan bool
+}
+
+type savedSnapshot struct {
+	turn int
+	path string
+}
+
+// autosaveQueue is the most snapshots waiting to be written before following the broker has to wait.
+const autosaveQueue = 4
+
+func newAutosaver(p Params) *autosaver {
+	a := &autosaver{
+		every:     p.SnapshotEvery,
+		interval:  p.SnapshotInterval,
+		turns:     make(map[int]bool),
+		last:      time.Now(),
+		offered:   p.StartTurn,
+		keep:      p.SnapshotKeep,
+		keepEvery: p.SnapshotKeepEvery,
+		queue:     make(chan util.Snapshot, autosaveQueue),
+		done:      make(chan bool),
+	}
+	for _, turn := range p.SnapshotTurns {
+		a.turns[turn] = true
+	}
+	go a.write()
+	return a
+}
+
+// due reports whether the generation at turn should be saved.
+// The broker skips generations after edits, a fast-forward or when it has no history left of the turn followed,
+// so a turn due to be saved that was skipped over is saved as the first generation after it instead.
+func (a *autosaver) due(turn int) bool {
+	from := a.offered
+	a.offered = turn
+	// The run went back to an earlier turn, as it does when seeking.
+	if turn <= from {
+		from = turn - 1
+	}
+	skipped := a.every > 0 && (turn-1)/a.every > from/a.every
+	for due := range a.turns {
+		if due > from && due < turn {
+			skipped = true
+		}
+	}
+	if skipped {
+		log.Printf("[IO] %v Turns %v to %v were skipped, saving turn %v instead", util.Yellow("WARN"), from+1, turn-1, turn)
+		return true
+	}
+	if a.every > 0 && turn%a.every == 0 {
+		return true
+	}
+	if a.interval > 0 && time.Since(a.last) >= a.interval {
+		return true
+	}
+	return a.turns[turn]
+}
+
+// offer queues a snapshot of world if the generation at turn is due to be saved.
+func (a *autosaver) offer(world [][]byte, turn int) {
+	if !a.due(turn) {
+		return
+	}
+	a.last = time.Now()
+	copied := make([][]byte, len(world))
+	for i := range world {
+		copied[i] = append([]byte(nil), world[i]...)
+	}
+	snapshot := util.NewSnapshot(copied, turn)
+	snapshot.Source = p.source()
+	a.queue <- snapshot
+}
+
+// write saves the queued snapshots until the queue is closed.
+func (a *autosaver) write() {
+	for snapshot := range a.queue {
+		outFile := p.outName(snapshot.Turn) + ".snap"
+		path := filepath.Join(p.outDir(), outFile)
+		_ = os.MkdirAll(p.outDir(), os.ModePerm)
+		if err := util.SaveSnapshot(path, snapshot); err != nil {
+			log.Printf("[IO] %v Failed to write %v: %v", util.Red("ERROR"), outFile, err)
+			continue
+		}
+		log.Printf("[IO] File %v output done", outFile)
+		c.events <- ImageOutputComplete{snapshot.Turn, outFile}
+		a.saved = append(a.saved, savedSnapshot{snapshot.Turn, path})
+		a.prune()
+	}
+	a.done <- true
+}
+
+// prune deletes the snapshots the retention policy no longer keeps:
+// everything but the last SnapshotKeep snapshots and the first of every SnapshotKeepEvery turns.
+func (a *autosaver) prune() {
+	if a.keep <= 0 {
+		return
+	}
+	kept := a.saved[:0]
+	buckets := make(map[int]bool)
+	for i, s := range a.saved {
+		keep := i >= len(a.saved)-a.keep
+		if a.keepEvery > 0 && !buckets[s.turn/a.keepEvery] {
+			buckets[s.turn/a.keepEvery] = true
+			keep = true
+		}
+		if keep {
+			kept = append(kept, s)
+		} else if err := os.Remove(s.path); err != nil {
+			log.Printf("[IO] %v %v", util.Red("ERROR"), err)
+		}
+	}
+	a.saved = kept
+}
+
+// close waits for the queued snapshots to be written.
+func (a *autosaver) close() {
+	close(a.queue)
+	<-a.done
+}
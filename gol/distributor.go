@@ -3,10 +3,15 @@ package gol
 import (
 	"flag"
 	"fmt"
+	"log"
 	"net/rpc"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
 var (
@@ -15,15 +20,33 @@ var (
 
 	pBroker *string
 	client  *rpc.Client
+	// editClient is the connection to the broker the front ends edit the world through, nil when there is none.
+	// Unlike client, it is used from the goroutines of the front ends, so it is guarded by editClientM.
+	editClient  *rpc.Client
+	editClientM sync.Mutex
 
 	p Params
 	c distributorChannels
 
 	tickerTriggers      = make(chan bool)
+	statisticsTriggers  = make(chan bool)
+	followerTriggers    = make(chan bool)
 	keyListenerTriggers = make(chan bool)
 	pauseKeyPresses     = make(chan rune)
 
-	turns int
+	// shown is the world as currently displayed by the front end.
+	shown [][]byte
+
+	// followed tracks the broker's generations for the GIF recording, automatic snapshots and live front ends.
+	followed  feed
+	recording *animation
+	autosaves *autosaver
+
+	reportedPeriod  int
+	reportedPeriodM sync.Mutex
+
+	// turnRate is the limit on turns per second the broker runs at, changed with the + and - keys.
+	turnRate int
 )
 
 type distributorChannels struct {
@@ -31,9 +54,21 @@ type distributorChannels struct {
 	keyPresses <-chan rune
 	ioCommand  chan<- ioCommand
 	ioIdle     <-chan bool
+	ioErrors   <-chan error
 	ioFilename chan<- string
-	ioOutput   chan<- uint8
-	ioInput    <-chan uint8
+	ioTurn     chan<- int
+	ioOutput   chan<- []byte
+	ioInput    <-chan []byte
+}
+
+// reportStability sends StabilityDetected the first time the broker finds the world to be periodic.
+func reportStability(stableTurns, period int) {
+	reportedPeriodM.Lock()
+	defer reportedPeriodM.Unlock()
+	if period != 0 && period != reportedPeriod {
+		reportedPeriod = period
+		c.events <- StabilityDetected{stableTurns, period}
+	}
 }
 
 // ticker report the number of cells that are still alive every 2 seconds when gol is running
@@ -51,8 +86,9 @@ func ticker(seconds time.Duration) {
 					panic(err)
 				}
 
+				reportStability(response.StableTurns, response.Period)
 				c.events <- AliveCellsCount{
-					p.Turns - turns + response.CompletedTurns,
+					response.CompletedTurns,
 					response.CellsCount,
 				}
 			case <-tickerTriggers:
@@ -62,6 +98,45 @@ func ticker(seconds time.Duration) {
 	}
 }
 
+// drainStatistics sends the TurnStatistics collected by the broker since the last call.
+func drainStatistics() {
+	response := new(stubs.StatisticsResponse)
+	err := client.Call(stubs.StatisticsHandler, stubs.StatisticsRequest{}, response)
+	if err != nil {
+		panic(err)
+	}
+	for _, stats := range response.Turns {
+		c.events <- TurnStatistics{
+			stats.CompletedTurns,
+			stats.Births,
+			stats.Deaths,
+			stats.Edited,
+			stats.Skipped,
+			stats.Population,
+			stats.TopLeft,
+			stats.BottomRight,
+		}
+	}
+}
+
+// statistician forwards per-turn statistics from the broker while gol is running
+func statistician(interval time.Duration) {
+	for {
+		<-statisticsTriggers
+	out:
+		for {
+			select {
+			case <-time.After(interval):
+				if p.Statistics {
+					drainStatistics()
+				}
+			case <-statisticsTriggers:
+				break out
+			}
+		}
+	}
+}
+
 func keyListener() {
 	for {
 		<-keyListenerTriggers
@@ -85,20 +160,7 @@ func keyListener() {
 						panic(err)
 					}
 
-					outFile := fmt.Sprintf("%dx%dx%d", p.ImageWidth, p.ImageHeight, response.CompletedTurns)
-					c.ioCommand <- ioOutput
-					c.ioFilename <- outFile
-
-					for i := range response.World {
-						for j := range response.World[i] {
-							c.ioOutput <- response.World[i][j]
-						}
-					}
-
-					// Make sure that the Io has finished any output before exiting.
-					c.ioCommand <- ioCheckIdle
-					<-c.ioIdle
-					c.events <- ImageOutputComplete{response.CompletedTurns, outFile}
+					outputWorld(response.World, response.CompletedTurns)
 				case 'q':
 					err := client.Call(stubs.PauseHandler, stubs.PauseRequest{}, new(stubs.PauseResponse))
 					if err != nil {
@@ -119,6 +181,14 @@ func keyListener() {
 						panic(err)
 					}
 					paused = true
+				case '+', '-':
+					turnRate = nextTurnRate(turnRate, key == '+')
+					response := new(stubs.TurnRateResponse)
+					err := client.Call(stubs.TurnRateHandler, stubs.TurnRateRequest{TurnRate: turnRate}, response)
+					if err != nil {
+						panic(err)
+					}
+					c.events <- TurnRateChanged{response.CompletedTurns, turnRate}
 				}
 			case <-keyListenerTriggers:
 				break out
@@ -127,6 +197,72 @@ func keyListener() {
 	}
 }
 
+// outputWorld has the io goroutine write world as an image of the given turn.
+func outputWorld(world [][]byte, turn int) {
+	outFile := p.outName(turn)
+	c.ioCommand <- ioOutput
+	c.ioFilename <- outFile
+	c.ioTurn <- turn
+
+	// The rows are shared with the io goroutine, which is done with them once it reports back.
+	for _, row := range world {
+		c.ioOutput <- row
+	}
+
+	err := <-c.ioErrors
+
+	// Make sure that the Io has finished any output before exiting.
+	c.ioCommand <- ioCheckIdle
+	<-c.ioIdle
+	if err != nil {
+		log.Printf("[IO] %v Failed to write %v: %v", util.Red("ERROR"), outFile, err)
+		return
+	}
+	if p.OutputFormat != "" && p.OutputFormat != "pgm" {
+		outFile += "." + p.OutputFormat
+	}
+	c.events <- ImageOutputComplete{turn, outFile}
+}
+
+// outputRecording writes the GIF recording of the run up to the given turn.
+func outputRecording(turn int) {
+	outFile := p.outName(turn) + ".gif"
+	if err := recording.save(filepath.Join(p.outDir(), outFile)); err != nil {
+		log.Printf("[IO] %v Failed to write %v: %v", util.Red("ERROR"), outFile, err)
+		return
+	}
+	log.Printf("[IO] File %v output done", outFile)
+	c.events <- ImageOutputComplete{turn, outFile}
+}
+
+func calculateAliveCells(world [][]byte) []util.Cell {
+	var aliveCells []util.Cell
+	for i := range world {
+		for j := range world[i] {
+			if world[i][j] == 255 {
+				aliveCells = append(aliveCells, util.Cell{X: j, Y: i})
+			}
+		}
+	}
+	return aliveCells
+}
+
+// show sends the cells that differ between world and what the front end currently displays.
+func show(world [][]byte, turn int) {
+	var cells []util.Cell
+	for i := range world {
+		for j := range world[i] {
+			if world[i][j] != shown[i][j] {
+				shown[i][j] = world[i][j]
+				cells = append(cells, util.Cell{X: j, Y: i})
+			}
+		}
+	}
+	if len(cells) > 0 {
+		c.events <- CellsFlipped{turn, cells}
+	}
+}
+
 // distributor divides the work between workers and interacts with other goroutines.
 func distributor(params Params, channels distributorChannels) {
 	if !inited {
@@ -136,84 +272,165 @@ func distributor(params Params, channels distributorChannels) {
 		flag.Parse()
 
 		go ticker(2)
+		go statistician(100 * time.Millisecond)
+		go follower(100 * time.Millisecond)
 		go keyListener()
 	}
 
 	p = params
 	c = channels
 
-	client, _ = rpc.Dial("tcp", *pBroker)
-	defer client.Close()
+	var dialErr error
+	if client, dialErr = rpc.Dial("tcp", *pBroker); dialErr != nil {
+		log.Printf("[Main] %v Failed to connect to the broker at %v: %v", util.Red("ERROR"), *pBroker, dialErr)
+		c.events <- StateChange{0, Quitting}
+		close(c.events)
+		return
+	}
+	editClientM.Lock()
+	editClient = client
+	editClientM.Unlock()
+	defer func() {
+		editClientM.Lock()
+		editClient = nil
+		editClientM.Unlock()
+		client.Close()
+	}()
 
 	c.ioCommand <- ioInput
-	c.ioFilename <- fmt.Sprintf("%dx%d", p.ImageWidth, p.ImageHeight)
+	if p.Pattern != "" {
+		c.ioFilename <- p.Pattern
+	} else if p.Input != "" {
+		c.ioFilename <- p.Input
+	} else {
+		c.ioFilename <- fmt.Sprintf("%dx%d", p.ImageWidth, p.ImageHeight)
+	}
+	if err := <-c.ioErrors; err != nil {
+		log.Printf("[IO] %v Failed to load the world from %v", util.Red("ERROR"), err)
+		c.events <- StateChange{0, Quitting}
+		close(c.events)
+		return
+	}
 
 	world := make([][]byte, p.ImageHeight)
+	shown = make([][]byte, p.ImageHeight)
 	for i := range world {
-		world[i] = make([]byte, p.ImageWidth)
+		world[i] = <-c.ioInput
+		shown[i] = make([]byte, p.ImageWidth)
 	}
 
-	for i := range world {
-		for j := range world[i] {
-			world[i][j] = <-c.ioInput
-		}
+	completed := p.StartTurn
+	show(world, completed)
+	turnRate = p.TurnRate
+	if turnRate > 0 {
+		c.events <- TurnRateChanged{completed, turnRate}
+	}
+	reportedPeriod = 0
+	recording = nil
+	if p.GifEvery > 0 {
+		recording = newAnimation(p.GifEvery, p.GifFrames, p.GifScale)
+		recording.add(world, completed)
+	}
+	autosaves = nil
+	if p.SnapshotEvery > 0 || p.SnapshotInterval > 0 || len(p.SnapshotTurns) > 0 {
+		autosaves = newAutosaver(p)
 	}
-
-	turns = p.Turns
 
 exe:
-	err := client.Call(stubs.PreBreakHandler, stubs.PreBreakRequest{}, new(stubs.PreBreakResponse))
+	preBreak := new(stubs.PreBreakResponse)
+	err := client.Call(stubs.PreBreakHandler, stubs.PreBreakRequest{}, preBreak)
 	if err != nil {
 		panic(err)
 	}
-	c.events <- StateChange{p.Turns - turns, Executing}
+	c.events <- StateChange{completed, Executing}
 
+	// A run resumed after its last turn has nothing left to do.
+	remaining := p.Turns - completed
+	if remaining < 0 {
+		remaining = 0
+	}
 	request := stubs.BreakWorldRequest{
-		Turns:       turns,
-		Threads:     p.Threads,
-		ImageWidth:  p.ImageWidth,
-		ImageHeight: p.ImageHeight,
-		World:       world,
+		Turns:          remaining,
+		CompletedTurns: completed,
+		Threads:        p.Threads,
+		ImageWidth:     p.ImageWidth,
+		ImageHeight:    p.ImageHeight,
+		World:          world,
+		FastForward:    p.FastForward,
+		Statistics:     p.Statistics,
+		TurnRate:       turnRate,
 	}
 	response := new(stubs.BreakWorldResponse)
 
+	followed.reset(world, completed, preBreak.Amendments)
+
 	keyListenerTriggers <- true
 	tickerTriggers <- true
+	statisticsTriggers <- true
+	followerTriggers <- true
 	err = client.Call(stubs.BreakWorldHandler, request, response)
 	if err != nil {
 		panic(err)
 	}
+	followerTriggers <- true
+	statisticsTriggers <- true
 	tickerTriggers <- true
 	keyListenerTriggers <- true
-
-	currTurns := p.Turns - turns + response.CompletedTurns
-
-	outputFile := func() {
-		outFile := fmt.Sprintf("%dx%dx%d", p.ImageWidth, p.ImageHeight, currTurns)
-		c.ioCommand <- ioOutput
-		c.ioFilename <- outFile
-
-		for i := range response.World {
-			for j := range response.World[i] {
-				c.ioOutput <- response.World[i][j]
-			}
+	if p.Statistics {
+		drainStatistics()
+	}
+	follow()
+
+	currTurns := response.CompletedTurns
+	reportStability(response.StableTurns, response.Period)
+
+	// seek restores a past generation from the broker's history for viewing and output.
+	seekTurn := ""
+	seek := func(turn int) {
+		seekResponse := new(stubs.SeekResponse)
+		err := client.Call(stubs.SeekHandler, stubs.SeekRequest{Turn: turn}, seekResponse)
+		if err != nil {
+			panic(err)
 		}
-
-		// Make sure that the Io has finished any output before exiting.
-		c.ioCommand <- ioCheckIdle
-		<-c.ioIdle
-		c.events <- ImageOutputComplete{currTurns, outFile}
+		currTurns = seekResponse.CompletedTurns
+		response.World = seekResponse.World
+		response.AliveCells = calculateAliveCells(response.World)
+		show(response.World, currTurns)
+		c.events <- TurnComplete{currTurns}
+		c.events <- StateChange{currTurns, Paused}
 	}
 
 	if paused {
+		show(response.World, currTurns)
+		c.events <- TurnComplete{currTurns}
 		c.events <- StateChange{currTurns, Paused}
 		keyListenerTriggers <- true
 	}
 
 	for paused {
-		switch <-pauseKeyPresses {
+		switch key := <-pauseKeyPresses; key {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			seekTurn += string(key)
+			keyListenerTriggers <- false
+		case 'g':
+			if turn, err := strconv.Atoi(seekTurn); err == nil {
+				seek(turn)
+			}
+			seekTurn = ""
+			keyListenerTriggers <- false
+		case ',':
+			seek(currTurns - 1)
+			keyListenerTriggers <- false
+		case '.':
+			seek(currTurns + 1)
+			keyListenerTriggers <- false
 		case 's':
-			outputFile()
+			outputWorld(response.World, currTurns)
+			keyListenerTriggers <- false
+		case '+', '-':
+			// The broker is told the new rate when the run is resumed.
+			turnRate = nextTurnRate(turnRate, key == '+')
+			c.events <- TurnRateChanged{currTurns, turnRate}
 			keyListenerTriggers <- false
 		case 'q':
 			paused = false
@@ -228,15 +445,24 @@ exe:
 		case 'p':
 			paused = false
 			world = response.World
-			turns -= response.CompletedTurns
+			completed = currTurns
 			keyListenerTriggers <- true
 			goto exe
+		default:
+			keyListenerTriggers <- false
 		}
 	}
 
+	c.events <- EngineDetails{currTurns, *pBroker, response.Workers}
 	c.events <- FinalTurnComplete{currTurns, response.AliveCells}
 
-	outputFile()
+	outputWorld(response.World, currTurns)
+	if recording != nil {
+		outputRecording(currTurns)
+	}
+	if autosaves != nil {
+		autosaves.close()
+	}
 
 	c.events <- StateChange{currTurns, Quitting}
 
@@ -0,0 +1,67 @@
+package gol
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// EditCells sets and clears cells of the running world.
+// The edit is applied by the broker all at once at the next turn boundary.
+func EditCells(set, clear []util.Cell) error {
+	return edit(stubs.EditCellsRequest{Set: set, Clear: clear})
+}
+
+// PastePattern pastes the pattern file at path into the running world with its top left corner at offset.
+// The pattern overwrites the cells it covers and wraps around the edges of the world.
+// The format of the file is chosen by its extension, either a Netpbm image, a snapshot or one of the pattern formats.
+func PastePattern(path string, offset util.Cell) error {
+	grid, err := LoadPattern(path)
+	if err != nil {
+		return err
+	}
+	return edit(stubs.EditCellsRequest{Pattern: grid, Offset: offset})
+}
+
+// edit sends an edit to the broker of the current run. The connection is held for the call,
+// so the distributor does not close it while the edit is in flight.
+func edit(request stubs.EditCellsRequest) error {
+	editClientM.Lock()
+	defer editClientM.Unlock()
+	if editClient == nil {
+		return errors.New("not connected to a broker")
+	}
+	return editClient.Call(stubs.EditCellsHandler, request, new(stubs.EditCellsResponse))
+}
+
+// LoadPattern reads the image or pattern file at path as the grid of cells PastePattern pastes.
+func LoadPattern(path string) ([][]byte, error) {
+	if IsImage(path) {
+		return readWorldFile(path)
+	}
+	pat, err := readPatternFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := util.CheckWorldSize(pat.width, pat.height); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return pat.grid(), nil
+}
+
+// SaveSelection writes a grid of cells selected from the world at turn to the output directory as an RLE pattern,
+// returning its path. Selections saved at the same turn are numbered so that none is overwritten.
+func (p Params) SaveSelection(grid [][]byte, turn int) (string, error) {
+	name := p.outName(turn) + "-selection"
+	path := p.OutPath(name + ".rle")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = p.OutPath(fmt.Sprintf("%v%v.rle", name, i))
+	}
+	return path, saveImage(path, "rle", grid, turn, fmt.Sprintf("Selected from turn %v", turn))
+}
@@ -2,6 +2,9 @@ package gol
 
 import (
 	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
 
 	"uk.ac.bris.cs/gameoflife/util"
 )
@@ -15,6 +18,27 @@ type Event interface {
 	GetCompletedTurns() int
 }
 
+// eventTypes makes a new zero value of each Event type, given the name of the type, for decoding event logs.
+// Every Event type registers itself here with registerEvent, below its declaration.
+var eventTypes = map[string]func() interface{}{}
+
+// registerEvent adds the type of event to eventTypes, under the name event logs record it by.
+func registerEvent(event Event) bool {
+	t := reflect.TypeOf(event)
+	eventTypes[t.Name()] = func() interface{} { return reflect.New(t).Interface() }
+	return true
+}
+
+// EventTypes returns the names of every Event type that can be recorded and replayed, in order.
+func EventTypes() []string {
+	names := make([]string, 0, len(eventTypes))
+	for name := range eventTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // `AliveCellsCount` is an Event notifying the user about the number of currently alive cells.
 // This Event should be sent every 2s.
 type AliveCellsCount struct { // implements Event
@@ -22,6 +46,8 @@ type AliveCellsCount struct { // implements Event
 	CellsCount     int
 }
 
+var _ = registerEvent(AliveCellsCount{})
+
 // `ImageOutputComplete` is an Event notifying the user about the completion of output.
 // This Event should be sent every time an image has been saved.
 type ImageOutputComplete struct { // implements Event
@@ -29,6 +55,8 @@ type ImageOutputComplete struct { // implements Event
 	Filename       string
 }
 
+var _ = registerEvent(ImageOutputComplete{})
+
 // State represents a change in the state of execution.
 type State int
 
@@ -45,6 +73,8 @@ type StateChange struct { // implements Event
 	NewState       State
 }
 
+var _ = registerEvent(StateChange{})
+
 // `CellFlipped` is an Event notifying the GUI about a change of state of a single cell.
 // This event should be sent every time a cell changes state.
 // Make sure to send this event for all cells that are alive when the image is loaded in.
@@ -53,6 +83,8 @@ type CellFlipped struct { // implements Event
 	Cell           util.Cell
 }
 
+var _ = registerEvent(CellFlipped{})
+
 // `CellsFlipped` is an Event notifying the GUI about a change of state of many cells.
 // You can collect many flipped cells and send `CellsFlipped` at a time instead of sending `CellFlipped` for every flipped cell.
 // You can send many times of `CellsFlipped` event in a turn, i.e., each worker could send `CellsFlipped`.
@@ -63,6 +95,8 @@ type CellsFlipped struct { // implements Event
 	Cells          []util.Cell
 }
 
+var _ = registerEvent(CellsFlipped{})
+
 // `TurnComplete` is an Event notifying the GUI about turn completion.
 // SDL will render a frame when this event is sent.
 // All `CellFlipped` or `CellsFlipped` events must be sent *before* `TurnComplete`.
@@ -70,6 +104,8 @@ type TurnComplete struct { // implements Event
 	CompletedTurns int
 }
 
+var _ = registerEvent(TurnComplete{})
+
 // `FinalTurnComplete` is an Event notifying the testing framework about the new world state after execution finished.
 // The data included with this Event is used directly by the tests.
 // SDL closes the window when this Event is sent.
@@ -78,6 +114,58 @@ type FinalTurnComplete struct {
 	Alive          []util.Cell
 }
 
+var _ = registerEvent(FinalTurnComplete{})
+
+// `TurnStatistics` is an Event reporting how the population changed during a single turn.
+// This Event is sent for every completed turn when statistics are enabled in Params.
+// Edited is the change in population from cells edited before the turn, so that the Population
+// is always that of the turn before plus Edited and Births, less Deaths.
+// When turns are skipped by fast-forwarding, a single Event marks the skip, with the number of turns
+// skipped as Skipped and no births or deaths.
+// TopLeft and BottomRight give the bounding box of the alive cells.
+type TurnStatistics struct { // implements Event
+	CompletedTurns int
+	Births         int
+	Deaths         int
+	Edited         int
+	Skipped        int
+	Population     int
+	TopLeft        util.Cell
+	BottomRight    util.Cell
+}
+
+var _ = registerEvent(TurnStatistics{})
+
+// `StabilityDetected` is an Event notifying the user that the world has started repeating itself.
+// This Event is sent once when the world is found to repeat with the given period.
+// A Period of 1 means the world has become a still life.
+type StabilityDetected struct { // implements Event
+	CompletedTurns int
+	Period         int
+}
+
+var _ = registerEvent(StabilityDetected{})
+
+// `TurnRateChanged` is an Event notifying the user that the limit on the turns run per second has changed.
+// This Event is sent at the start of a limited run and every time the limit is changed.
+// A TurnRate of 0 means the run is not limited.
+type TurnRateChanged struct { // implements Event
+	CompletedTurns int
+	TurnRate       int
+}
+
+var _ = registerEvent(TurnRateChanged{})
+
+// `EngineDetails` is an Event describing what computed the run: the address of the broker and of the workers it used.
+// This Event is sent once, just before FinalTurnComplete.
+type EngineDetails struct { // implements Event
+	CompletedTurns int
+	Broker         string
+	Workers        []string
+}
+
+var _ = registerEvent(EngineDetails{})
+
 // String methods allow the different types of Events and States to be printed.
 
 func (state State) String() string {
@@ -110,7 +198,11 @@ func (event AliveCellsCount) GetCompletedTurns() int {
 }
 
 func (event ImageOutputComplete) String() string {
-	return fmt.Sprintf("File %v.pgm output done", event.Filename)
+	// Pgm images are the default and are named without their extension.
+	if filepath.Ext(event.Filename) == "" {
+		return fmt.Sprintf("File %v.pgm output done", event.Filename)
+	}
+	return fmt.Sprintf("File %v output done", event.Filename)
 }
 
 func (event ImageOutputComplete) GetCompletedTurns() int {
@@ -141,6 +233,44 @@ func (event TurnComplete) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event TurnStatistics) String() string {
+	return fmt.Sprintf("Population %v (+%v -%v)", event.Population, event.Births, event.Deaths)
+}
+
+func (event TurnStatistics) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event StabilityDetected) String() string {
+	if event.Period == 1 {
+		return "Still life detected"
+	}
+	return fmt.Sprintf("Oscillator with period %v detected", event.Period)
+}
+
+func (event StabilityDetected) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event TurnRateChanged) String() string {
+	if event.TurnRate == 0 {
+		return "Turn rate unlimited"
+	}
+	return fmt.Sprintf("Turn rate limited to %v turns/sec", event.TurnRate)
+}
+
+func (event TurnRateChanged) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event EngineDetails) String() string {
+	return fmt.Sprintf("Broker %v with %v workers", event.Broker, len(event.Workers))
+}
+
+func (event EngineDetails) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 func (event FinalTurnComplete) String() string {
 	return "Final Turn Complete"
 }
@@ -0,0 +1,114 @@
+package gol
+
+import (
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// generationsLimit is the most generations requested from the broker at once.
+const generationsLimit = 1000
+
+// feed is a copy of the broker's world that is kept up to date one generation at a time.
+type feed struct {
+	turn       int
+	amendments int
+	world      [][]byte
+}
+
+// reset starts following a run of the broker from world at the given turn.
+func (f *feed) reset(world [][]byte, turn, amendments int) {
+	f.turn = turn
+	f.amendments = amendments
+	f.world = make([][]byte, len(world))
+	for i := range world {
+		f.world[i] = append([]byte(nil), world[i]...)
+	}
+}
+
+// apply brings the world up to the given generation.
+func (f *feed) apply(g stubs.Generation) {
+	f.turn = g.CompletedTurns
+	if g.Keyframe != nil {
+		f.world = util.UnpackWorld(g.Keyframe, p.ImageWidth, p.ImageHeight)
+		return
+	}
+	for _, i := range g.Flips {
+		f.world[int(i)/p.ImageWidth][int(i)%p.ImageWidth] ^= 255
+	}
+}
+
+// following reports whether anything needs the generations computed by the broker.
+func following() bool {
+	return (recording != nil && !recording.full()) || autosaves != nil || p.Live
+}
+
+// showGeneration sends the cells of the generation just applied that differ from what the front end displays.
+func showGeneration(g stubs.Generation) {
+	if g.Keyframe != nil {
+		show(followed.world, followed.turn)
+	} else if len(g.Flips) > 0 {
+		cells := make([]util.Cell, 0, len(g.Flips))
+		for _, i := range g.Flips {
+			x, y := int(i)%p.ImageWidth, int(i)/p.ImageWidth
+			if shown[y][x] != followed.world[y][x] {
+				shown[y][x] = followed.world[y][x]
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+		if len(cells) > 0 {
+			c.events <- CellsFlipped{followed.turn, cells}
+		}
+	}
+	c.events <- TurnComplete{followed.turn}
+}
+
+// follow fetches every generation computed since the last call and hands each one to the recording, autosaves
+// and, when live, the front end.
+func follow() {
+	for following() {
+		request := stubs.GenerationsRequest{
+			From:       followed.turn,
+			Amendments: followed.amendments,
+			Limit:      generationsLimit,
+		}
+		response := new(stubs.GenerationsResponse)
+		err := client.Call(stubs.GenerationsHandler, request, response)
+		if err != nil {
+			panic(err)
+		}
+		followed.amendments = response.Amendments
+		for _, g := range response.Generations {
+			followed.apply(g)
+			if recording != nil {
+				recording.add(followed.world, followed.turn)
+			}
+			if autosaves != nil {
+				autosaves.offer(followed.world, followed.turn)
+			}
+			if p.Live {
+				showGeneration(g)
+			}
+		}
+		if len(response.Generations) < generationsLimit {
+			return
+		}
+	}
+}
+
+// follower follows the generations computed by the broker while gol is running
+func follower(interval time.Duration) {
+	for {
+		<-followerTriggers
+	out:
+		for {
+			select {
+			case <-time.After(interval):
+				follow()
+			case <-followerTriggers:
+				break out
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package gol
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// FrameFormats are the formats frames can be exported in.
+// Frames in png or ppm are written as a numbered sequence of images, y4m frames as a single uncompressed video.
+var FrameFormats = []string{"png", "ppm", "y4m"}
+
+// FrameExporter writes every Nth generation of a run as a frame, keeping its own copy of the board
+// from the CellsFlipped and TurnComplete events it forwards. It needs Params.Live to see every generation.
+type FrameExporter struct {
+	format       string
+	every, scale int
+	fps          int
+	dir          string
+	width        int
+	height       int
+	world        [][]byte
+	// next is the first turn to be exported as the next frame, and frames the number of frames exported so far.
+	next   int
+	frames int
+	file   *os.File
+	video  *bufio.Writer
+	failed bool
+	closed bool
+}
+
+// NewFrameExporter exports the frames of a run with the given Params to path.
+// For png and ppm, path is a directory that the frames are numbered in from 000000.
+// For y4m, path is the video file, or - for stdout so that it can be piped straight into an encoder,
+// played at fps frames per second. Each cell is drawn as a scale x scale square.
+func NewFrameExporter(path, format string, every, scale, fps int, p Params) (*FrameExporter, error) {
+	if every < 1 {
+		every = 1
+	}
+	if scale < 1 {
+		scale = 1
+	}
+	f := &FrameExporter{
+		format: format,
+		every:  every,
+		scale:  scale,
+		fps:    fps,
+		dir:    path,
+		width:  p.ImageWidth,
+		height: p.ImageHeight,
+		world:  make([][]byte, p.ImageHeight),
+		next:   p.StartTurn,
+	}
+	for i := range f.world {
+		f.world[i] = make([]byte, p.ImageWidth)
+	}
+
+	switch format {
+	case "png", "ppm":
+		if err := os.MkdirAll(path, os.ModePerm); err != nil {
+			return nil, err
+		}
+	case "y4m":
+		f.file = os.Stdout
+		if path != "-" {
+			_ = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+			file, err := os.Create(path)
+			if err != nil {
+				return nil, err
+			}
+			f.file = file
+		}
+		f.video = bufio.NewWriterSize(f.file, ioBufferSize)
+		// Cells are drawn in the limited range of video, with the chroma planes of 4:2:0 grey throughout.
+		_, _ = fmt.Fprintf(f.video, "YUV4MPEG2 W%v H%v F%v:1 Ip A1:1 C420jpeg\n", f.width*scale, f.height*scale, fps)
+	default:
+		return nil, fmt.Errorf("unknown frame format %v", format)
+	}
+	return f, nil
+}
+
+// Forward exports frames from the events received from in and passes them on to out.
+// The first frame is the world the run starts from, and the rest follow every N turns as they complete.
+// The video is closed, and so is out, once in is closed.
+func (f *FrameExporter) Forward(in <-chan Event, out chan<- Event) {
+	started := false
+	for event := range in {
+		switch e := event.(type) {
+		case CellFlipped:
+			f.world[e.Cell.Y][e.Cell.X] ^= 0xFF
+		case CellsFlipped:
+			for _, cell := range e.Cells {
+				f.world[cell.Y][cell.X] ^= 0xFF
+			}
+		case StateChange:
+			if !started {
+				started = true
+				f.export(e.CompletedTurns)
+			}
+			// The front end may exit as soon as it sees the run quit, so everything must be written by then.
+			if e.NewState == Quitting {
+				f.close()
+			}
+		case TurnComplete:
+			f.export(e.CompletedTurns)
+		}
+		out <- event
+	}
+	f.close()
+	close(out)
+}
+
+// close closes the exporter once, logging rather than returning any error.
+func (f *FrameExporter) close() {
+	if f.closed {
+		return
+	}
+	f.closed = true
+	if err := f.Close(); err != nil {
+		log.Printf("[Frames] %v %v", util.Red("ERROR"), err)
+	}
+}
+
+// export writes the world as the next frame if it is due, giving up on the first error.
+// Turns before the next one due are skipped, so seeking back while paused does not export anything.
+func (f *FrameExporter) export(turn int) {
+	if f.failed || f.closed || turn < f.next {
+		return
+	}
+	f.next = turn + f.every
+
+	var err error
+	if f.format == "y4m" {
+		err = f.writeVideoFrame()
+	} else {
+		path := filepath.Join(f.dir, fmt.Sprintf("%06d.%v", f.frames, f.format))
+		err = saveImage(path, f.format, scaleWorld(f.world, f.scale), turn, "")
+	}
+	if err != nil {
+		log.Printf("[Frames] %v Failed to export the frame of turn %v: %v", util.Red("ERROR"), turn, err)
+		f.failed = true
+		return
+	}
+	f.frames++
+}
+
+// writeVideoFrame writes the world as a frame of the video, one row of cells at a time.
+func (f *FrameExporter) writeVideoFrame() error {
+	_, _ = f.video.WriteString("FRAME\n")
+	line := make([]byte, f.width*f.scale)
+	for _, row := range f.world {
+		for x, cell := range row {
+			luma := byte(16)
+			if cell == 0xFF {
+				luma = 235
+			}
+			for i := 0; i < f.scale; i++ {
+				line[x*f.scale+i] = luma
+			}
+		}
+		for i := 0; i < f.scale; i++ {
+			if _, err := f.video.Write(line); err != nil {
+				return err
+			}
+		}
+	}
+	chroma := make([]byte, (f.width*f.scale+1)/2)
+	for i := range chroma {
+		chroma[i] = 128
+	}
+	for i := 0; i < 2*((f.height*f.scale+1)/2); i++ {
+		if _, err := f.video.Write(chroma); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes the video, if there is one, and reports how many frames were exported.
+func (f *FrameExporter) Close() error {
+	if f.video != nil {
+		if err := f.video.Flush(); err != nil {
+			return err
+		}
+		if f.file != os.Stdout {
+			if err := f.file.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("[Frames] Exported %v frames to %v", f.frames, f.dir)
+	return nil
+}
+
+// scaleWorld draws each cell of the world as a scale x scale square.
+func scaleWorld(world [][]byte, scale int) [][]byte {
+	if scale == 1 {
+		return world
+	}
+	scaled := make([][]byte, 0, len(world)*scale)
+	for _, row := range world {
+		line := make([]byte, len(row)*scale)
+		for x, cell := range row {
+			for i := 0; i < scale; i++ {
+				line[x*scale+i] = cell
+			}
+		}
+		for i := 0; i < scale; i++ {
+			scaled = append(scaled, line)
+		}
+	}
+	return scaled
+}
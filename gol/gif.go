@@ -0,0 +1,79 @@
+package gol
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+)
+
+// gifDelay is the time each frame of a recording is shown for, in hundredths of a second.
+const gifDelay = 10
+
+var gifPalette = color.Palette{color.Black, color.White}
+
+// animation records every Nth generation of a run as the frames of an animated GIF.
+type animation struct {
+	every, limit, scale int
+	last                int
+	gif                 gif.GIF
+}
+
+func newAnimation(every, limit, scale int) *animation {
+	if scale < 1 {
+		scale = 1
+	}
+	return &animation{every: every, limit: limit, scale: scale, last: -1}
+}
+
+// full reports whether the animation has reached its frame limit.
+func (a *animation) full() bool {
+	return a.limit > 0 && len(a.gif.Image) >= a.limit
+}
+
+// add records world as the next frame if turn is one of the generations to record.
+// Turns at or before the last recorded frame are ignored, so the animation always moves forwards.
+func (a *animation) add(world [][]byte, turn int) {
+	if a.full() || turn%a.every != 0 || turn <= a.last {
+		return
+	}
+	a.last = turn
+
+	height := len(world)
+	width := 0
+	if height > 0 {
+		width = len(world[0])
+	}
+	frame := image.NewPaletted(image.Rect(0, 0, width*a.scale, height*a.scale), gifPalette)
+	for y, row := range world {
+		for x, cell := range row {
+			if cell != 255 {
+				continue
+			}
+			for i := 0; i < a.scale; i++ {
+				start := frame.PixOffset(x*a.scale, y*a.scale+i)
+				for j := 0; j < a.scale; j++ {
+					frame.Pix[start+j] = 1
+				}
+			}
+		}
+	}
+	a.gif.Image = append(a.gif.Image, frame)
+	a.gif.Delay = append(a.gif.Delay, gifDelay)
+}
+
+// save writes the recorded frames to path.
+func (a *animation) save(path string) error {
+	_ = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := gif.EncodeAll(file, &a.gif); err != nil {
+		return err
+	}
+	return file.Sync()
+}
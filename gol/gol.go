@@ -1,27 +1,86 @@
 package gol
 
+import (
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
 // Params provides the details of how to run the Game of Life and which image to load.
+// The image is read from Input when given, otherwise from images/<width>x<height>.pgm.
+// A Pattern file is loaded instead of the image when given, centred on the board unless PlacePattern is set.
+// With Soup set the world is generated at random from Seed instead, with cells alive at the given Density
+// and an optional Symmetry, one of Symmetries.
+// Snapshots are saved automatically every SnapshotEvery turns, every SnapshotInterval and at each of SnapshotTurns.
+// With SnapshotKeep set, only the last SnapshotKeep of them are kept, plus the first of every SnapshotKeepEvery turns.
+// A run resumed from a saved world starts at StartTurn and runs until Turns have been completed in total.
+// Output files are written to OutDir, named by the OutName template (see DefaultOutName).
+// With GifEvery set, every GifEvery-th generation is recorded into an animated GIF of at most GifFrames frames,
+// each cell drawn as a GifScale x GifScale square.
+// With Live set, every generation computed by the broker is sent as CellsFlipped and TurnComplete while running.
+// The broker runs at most TurnRate turns per second, or as fast as it can with a TurnRate of 0.
+// In headless mode, a JSON summary of the run is written to SummaryFile when it ends.
 type Params struct {
-	Turns       int
-	Threads     int
-	ImageWidth  int
-	ImageHeight int
+	Turns        int
+	StartTurn    int
+	Threads      int
+	ImageWidth   int
+	ImageHeight  int
+	Input        string
+	Pattern      string
+	PatternAt    util.Cell
+	PlacePattern bool
+	Soup         bool
+	Seed         int64
+	Density      float64
+	Symmetry     string
+	OutputFormat string
+	OutDir       string
+	OutName      string
+	PasteFile    string
+	FastForward  bool
+	Statistics   bool
+	StatsFile    string
+	SummaryFile  string
+	GifEvery     int
+	GifFrames    int
+	GifScale     int
+	Live         bool
+	TurnRate     int
+
+	SnapshotEvery     int
+	SnapshotInterval  time.Duration
+	SnapshotTurns     []int
+	SnapshotKeep      int
+	SnapshotKeepEvery int
+
+	// origin is the source recorded in the snapshot the world was read from, kept for the outputs of a resumed run.
+	origin string
 }
 
 // Run starts the processing of Game of Life. It should initialise channels and goroutines.
 func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
+	if !p.Soup && p.Pattern == "" && isSnapshot(p.Input) {
+		if s, err := util.ReadSnapshotHeader(p.Input); err == nil {
+			p.origin = s.Source
+		}
+	}
 
 	ioFilename := make(chan string)
-	ioOutput := make(chan uint8)
-	ioInput := make(chan uint8)
+	ioTurn := make(chan int)
+	ioOutput := make(chan []byte)
+	ioInput := make(chan []byte)
 
 	ioCommand := make(chan ioCommand)
 	ioIdle := make(chan bool)
+	ioErrors := make(chan error)
 
 	ioChannels := ioChannels{
 		command:  ioCommand,
 		idle:     ioIdle,
+		errors:   ioErrors,
 		filename: ioFilename,
+		turn:     ioTurn,
 		output:   ioOutput,
 		input:    ioInput,
 	}
@@ -32,7 +91,9 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 		keyPresses: keyPresses,
 		ioCommand:  ioCommand,
 		ioIdle:     ioIdle,
+		ioErrors:   ioErrors,
 		ioFilename: ioFilename,
+		ioTurn:     ioTurn,
 		ioOutput:   ioOutput,
 		ioInput:    ioInput,
 	}
@@ -1,11 +1,14 @@
 package gol
 
 import (
+	"bufio"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
 	"log"
 	"os"
-	"strconv"
-	"strings"
+	"path/filepath"
 
 	"uk.ac.bris.cs/gameoflife/util"
 )
@@ -13,10 +16,12 @@ import (
 type ioChannels struct {
 	command <-chan ioCommand
 	idle    chan<- bool
+	errors  chan<- error
 
 	filename <-chan string
-	output   <-chan uint8
-	input    chan<- uint8
+	turn     <-chan int
+	output   <-chan []byte
+	input    chan<- []byte
 }
 
 // ioState is the internal ioState of the io goroutine.
@@ -26,6 +31,7 @@ type ioState struct {
 }
 
 // ioCommand allows requesting behaviour from the io (pgm) goroutine.
+// Worlds are sent to and from the io goroutine a whole row at a time.
 type ioCommand uint8
 
 // This is a way of creating enums in Go.
@@ -40,88 +46,180 @@ const (
 	ioCheckIdle
 )
 
-// writePgmImage receives an array of bytes and writes it to a pgm file.
-func (io *ioState) writePgmImage() {
-	_ = os.Mkdir("out", os.ModePerm)
-
-	// Request a filename from the distributor.
-	filename := <-io.channels.filename
-
-	file, ioError := os.Create("out/" + filename + ".pgm")
-	util.Check(ioError)
-	defer file.Close()
-
-	_, _ = file.WriteString("P5\n")
-	//_, _ = file.WriteString("# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageWidth))
-	_, _ = file.WriteString(" ")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageHeight))
-	_, _ = file.WriteString("\n")
-	_, _ = file.WriteString(strconv.Itoa(255))
-	_, _ = file.WriteString("\n")
+// OutputFormats lists the formats output images can be written in.
+// Each format is also the extension of the files written in it.
+var OutputFormats = []string{"pgm", "pbm", "ppm", "png", "rle", "cells", "lif", "life", "snap"}
 
+// receiveWorld receives the world from the distributor one row at a time.
+// The rows are not copied, so they must not be changed until the image has been written.
+func (io *ioState) receiveWorld() [][]byte {
 	world := make([][]byte, io.params.ImageHeight)
-	for i := range world {
-		world[i] = make([]byte, io.params.ImageWidth)
+	for y := range world {
+		world[y] = <-io.channels.output
 	}
+	return world
+}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
-			val := <-io.channels.output
-			world[y][x] = val
-		}
+// writePng writes the world as a greyscale png image.
+func writePng(w io.Writer, world [][]byte) error {
+	height := len(world)
+	width := 0
+	if height > 0 {
+		width = len(world[0])
 	}
-
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
-			_, ioError = file.Write([]byte{world[y][x]})
-			util.Check(ioError)
-		}
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y, row := range world {
+		copy(img.Pix[y*img.Stride:], row)
 	}
+	return png.Encode(w, img)
+}
 
-	ioError = file.Sync()
-	util.Check(ioError)
+// writeImage receives an array of bytes and writes it to a file in the configured output format.
+// Any error is reported back to the distributor instead of stopping the io goroutine.
+func (io *ioState) writeImage() {
+	// Request a filename and the turn of the world from the distributor.
+	filename := <-io.channels.filename
+	turn := <-io.channels.turn
+	world := io.receiveWorld()
 
-	log.Printf("[IO] File %v.pgm output done", filename)
+	format := io.params.OutputFormat
+	if format == "" {
+		format = "pgm"
+	}
+	filename += "." + format
+
+	err := saveImage(filepath.Join(io.params.outDir(), filename), format, world, turn, io.params.source())
+	io.channels.errors <- err
+	if err == nil {
+		log.Printf("[IO] File %v output done", filename)
+	}
 }
 
-// readPgmImage opens a pgm file and sends its data as an array of bytes.
-func (io *ioState) readPgmImage() {
+// ioBufferSize is the size of the buffer images are streamed to their file through.
+const ioBufferSize = 1 << 20
 
-	// Request a filename from the distributor.
-	filename := <-io.channels.filename
+// saveImage writes the world at turn to path in the given format.
+// The comment records where the world came from, in formats that have room for it.
+func saveImage(path, format string, world [][]byte, turn int, comment string) error {
+	_ = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
-	data, ioError := os.ReadFile("images/" + filename + ".pgm")
-	util.Check(ioError)
+	// The writers buffer their output themselves, which reuses this buffer as it is large enough.
+	out := bufio.NewWriterSize(file, ioBufferSize)
+	switch format {
+	case "pgm":
+		err = writePgm(out, world, comment)
+	case "pbm":
+		err = writePbm(out, world, comment)
+	case "ppm":
+		err = writePpm(out, world, comment)
+	case "png":
+		err = writePng(out, world)
+	case "rle":
+		err = writeRle(out, world, comment)
+	case "cells":
+		err = writeCells(out, world, comment)
+	case "lif":
+		err = writeLife106(out, world, comment)
+	case "life":
+		err = writeLife105(out, world, comment)
+	case "snap":
+		snapshot := util.NewSnapshot(world, turn)
+		snapshot.Source = comment
+		err = util.WriteSnapshot(out, snapshot)
+	default:
+		err = fmt.Errorf("unknown output format %v", format)
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return err
+	}
 
-	fields := strings.Fields(string(data))
+	return file.Sync()
+}
 
-	if fields[0] != "P5" {
-		panic(fmt.Sprintf("[IO] %v %v is not a pgm file", util.Red("ERROR"), filename))
+// readWorldFile reads the world in the image or snapshot at path.
+func readWorldFile(path string) ([][]byte, error) {
+	if !isSnapshot(path) {
+		return readPnmFile(path)
 	}
+	s, err := util.LoadSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRule(s.Rule); err != nil {
+		return nil, err
+	}
+	if s.Topology != "torus" {
+		return nil, fmt.Errorf("unsupported topology %v", s.Topology)
+	}
+	return s.World, nil
+}
 
-	width, _ := strconv.Atoi(fields[1])
-	if width != io.params.ImageWidth {
-		panic(fmt.Sprintf("[IO] %v Incorrect pgm width", util.Red("ERROR")))
+// loadImage reads the image or snapshot at path, which must match the configured size.
+func (io *ioState) loadImage(path string) ([][]byte, error) {
+	world, err := readWorldFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	height, _ := strconv.Atoi(fields[2])
-	if height != io.params.ImageHeight {
-		panic(fmt.Sprintf("[IO] %v Incorrect pgm height", util.Red("ERROR")))
+	if height := len(world); height != io.params.ImageHeight {
+		return nil, fmt.Errorf("incorrect image height %v, expected %v", height, io.params.ImageHeight)
 	}
 
-	maxval, _ := strconv.Atoi(fields[3])
-	if maxval != 255 {
-		panic(fmt.Sprintf("[IO] %v Incorrect pgm maxval/bit depth", util.Red("ERROR")))
+	if len(world) > 0 && len(world[0]) != io.params.ImageWidth {
+		return nil, fmt.Errorf("incorrect image width %v, expected %v", len(world[0]), io.params.ImageWidth)
 	}
+	return world, nil
+}
 
-	image := []byte(fields[4])
+// loadPattern reads the pattern file at path and places it on an empty world.
+func (io *ioState) loadPattern(path string) ([][]byte, error) {
+	pat, err := readPatternFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return pat.place(io.params.ImageWidth, io.params.ImageHeight, io.params.PatternAt, !io.params.PlacePattern)
+}
+
+// readImage opens the image or pattern file and sends its data as an array of bytes.
+// If it can not be read, the error is sent to the distributor instead of the data.
+func (io *ioState) readImage() {
+
+	// Request a filename from the distributor.
+	filename := <-io.channels.filename
+
+	var world [][]byte
+	var err error
+	if io.params.Soup {
+		world, err = generateSoup(io.params.ImageWidth, io.params.ImageHeight, io.params.Seed, io.params.Density, io.params.Symmetry)
+		filename = io.params.source()
+	} else if io.params.Pattern != "" {
+		world, err = io.loadPattern(filename)
+	} else if io.params.Input != "" {
+		world, err = io.loadImage(filename)
+	} else {
+		world, err = io.loadImage("images/" + filename + ".pgm")
+		filename += ".pgm"
+	}
+	if err != nil {
+		io.channels.errors <- fmt.Errorf("%v: %v", filename, err)
+		return
+	}
+	io.channels.errors <- nil
 
-	for _, b := range image {
-		io.channels.input <- b
+	for _, row := range world {
+		io.channels.input <- row
 	}
 
-	log.Printf("[IO] File %v.pgm input done", filename)
+	log.Printf("[IO] File %v input done", filename)
 }
 
 // startIo should be the entrypoint of the io goroutine.
@@ -135,9 +233,9 @@ func startIo(p Params, c ioChannels) {
 		// Block and wait for requests from the distributor
 		switch command {
 		case ioInput:
-			io.readPgmImage()
+			io.readImage()
 		case ioOutput:
-			io.writePgmImage()
+			io.writeImage()
 		case ioCheckIdle:
 			io.channels.idle <- true
 		}
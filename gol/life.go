@@ -0,0 +1,230 @@
+package gol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// readCells parses a pattern in the plaintext .cells format.
+// Lines starting with ! are comments, and every other line is a row of dead (.) and alive (O or *) cells.
+func readCells(r io.Reader) (pattern, error) {
+	var pat pattern
+	scanner := bufio.NewScanner(r)
+	y := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(text, "!") {
+			continue
+		}
+		for x, ch := range text {
+			switch ch {
+			case '.':
+			case 'O', '*':
+				pat.cells = append(pat.cells, util.Cell{X: x, Y: y})
+			default:
+				return pat, fmt.Errorf("cells line %v: unexpected %q", line, ch)
+			}
+		}
+		if len(text) > pat.width {
+			pat.width = len(text)
+		}
+		y++
+	}
+	pat.height = y
+	return pat, scanner.Err()
+}
+
+// readLife parses a pattern in either Life 1.06, a list of "x y" coordinates,
+// or Life 1.05, where each #P block gives the position of its top left cell followed by rows of . and *.
+func readLife(r io.Reader) (pattern, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return pattern{}, err
+		}
+		return pattern{}, fmt.Errorf("life: empty file")
+	}
+	header := strings.TrimSpace(scanner.Text())
+
+	var cells []util.Cell
+	switch header {
+	case "#Life 1.06":
+		for line := 2; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			var cell util.Cell
+			if _, err := fmt.Sscan(text, &cell.X, &cell.Y); err != nil {
+				return pattern{}, fmt.Errorf("life line %v: malformed coordinates %q", line, text)
+			}
+			cells = append(cells, cell)
+		}
+
+	case "#Life 1.05":
+		var block util.Cell
+		y := 0
+		for line := 2; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			switch {
+			case text == "", strings.HasPrefix(text, "#D"), strings.HasPrefix(text, "#C"), strings.HasPrefix(text, "#N"):
+				continue
+			case strings.HasPrefix(text, "#R"):
+				if err := checkRule(text[2:]); err != nil {
+					return pattern{}, fmt.Errorf("life line %v: %v", line, err)
+				}
+				continue
+			case strings.HasPrefix(text, "#P"):
+				if _, err := fmt.Sscan(text[2:], &block.X, &block.Y); err != nil {
+					return pattern{}, fmt.Errorf("life line %v: malformed block %q", line, text)
+				}
+				y = 0
+				continue
+			case strings.HasPrefix(text, "#"):
+				return pattern{}, fmt.Errorf("life line %v: unknown line %q", line, text)
+			}
+			for x, ch := range text {
+				switch ch {
+				case '.':
+				case '*', 'O':
+					cells = append(cells, util.Cell{X: block.X + x, Y: block.Y + y})
+				default:
+					return pattern{}, fmt.Errorf("life line %v: unexpected %q", line, ch)
+				}
+			}
+			y++
+		}
+
+	default:
+		return pattern{}, fmt.Errorf("life: unknown header %q", header)
+	}
+	if err := scanner.Err(); err != nil {
+		return pattern{}, err
+	}
+	return fromCoordinates(cells), nil
+}
+
+// writeCells writes the whole world in the plaintext .cells format.
+func writeCells(w io.Writer, world [][]byte, comment string) error {
+	out := bufio.NewWriter(w)
+	height := len(world)
+	width := 0
+	if height > 0 {
+		width = len(world[0])
+	}
+	_, _ = fmt.Fprintf(out, "!Name: %vx%v\n", width, height)
+	if comment != "" {
+		_, _ = fmt.Fprintf(out, "!%v\n", comment)
+	}
+
+	line := make([]byte, width)
+	for _, row := range world {
+		for x, cell := range row {
+			if cell == 255 {
+				line[x] = 'O'
+			} else {
+				line[x] = '.'
+			}
+		}
+		_, _ = out.Write(line)
+		_ = out.WriteByte('\n')
+	}
+	return out.Flush()
+}
+
+// writeLife106 writes the alive cells of the world as Life 1.06 coordinates relative to the centre of the board.
+func writeLife106(w io.Writer, world [][]byte, comment string) error {
+	out := bufio.NewWriter(w)
+	_, _ = out.WriteString("#Life 1.06\n")
+	if comment != "" {
+		_, _ = fmt.Fprintf(out, "#D %v\n", comment)
+	}
+	centre := worldCentre(world)
+	for y, row := range world {
+		for x, cell := range row {
+			if cell == 255 {
+				_, _ = out.WriteString(strconv.Itoa(x - centre.X))
+				_ = out.WriteByte(' ')
+				_, _ = out.WriteString(strconv.Itoa(y - centre.Y))
+				_ = out.WriteByte('\n')
+			}
+		}
+	}
+	return out.Flush()
+}
+
+// life105Width is the widest block written in Life 1.05, which some readers can not go beyond.
+const life105Width = 80
+
+// writeLife105 writes the world in Life 1.05, as one #P block per band of 80 columns.
+// Blocks are positioned relative to the centre of the board, empty rows at either end of a band are left out
+// and so are the dead cells at the end of each row.
+func writeLife105(w io.Writer, world [][]byte, comment string) error {
+	out := bufio.NewWriter(w)
+	_, _ = out.WriteString("#Life 1.05\n")
+	if comment != "" {
+		_, _ = fmt.Fprintf(out, "#D %v\n", comment)
+	}
+	_, _ = out.WriteString("#R 23/3\n")
+	centre := worldCentre(world)
+	width := 0
+	if len(world) > 0 {
+		width = len(world[0])
+	}
+
+	for left := 0; left < width; left += life105Width {
+		right := left + life105Width
+		if right > width {
+			right = width
+		}
+		rowEnd := func(y int) int {
+			end := right
+			for end > left && world[y][end-1] != 255 {
+				end--
+			}
+			return end
+		}
+
+		top, bottom := 0, len(world)
+		for top < bottom && rowEnd(top) == left {
+			top++
+		}
+		for bottom > top && rowEnd(bottom-1) == left {
+			bottom--
+		}
+		if top == bottom {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(out, "#P %v %v\n", left-centre.X, top-centre.Y)
+		for y := top; y < bottom; y++ {
+			end := rowEnd(y)
+			if end == left {
+				_ = out.WriteByte('.')
+			}
+			for x := left; x < end; x++ {
+				if world[y][x] == 255 {
+					_ = out.WriteByte('*')
+				} else {
+					_ = out.WriteByte('.')
+				}
+			}
+			_ = out.WriteByte('\n')
+		}
+	}
+	return out.Flush()
+}
+
+// worldCentre is the cell that coordinate formats use as their origin.
+func worldCentre(world [][]byte) util.Cell {
+	width := 0
+	if len(world) > 0 {
+		width = len(world[0])
+	}
+	return util.Cell{X: width / 2, Y: len(world) / 2}
+}
@@ -0,0 +1,112 @@
+package gol
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// DefaultOutDir is the directory output files are written to when Params.OutDir is empty.
+const DefaultOutDir = "out"
+
+// DefaultOutName is the template output files are named by when Params.OutName is empty.
+// {w} and {h} are replaced by the size of the board, {turn} by the completed turns
+// and {name} by the name of the input file without its extension.
+const DefaultOutName = "{w}x{h}x{turn}"
+
+func (p Params) outDir() string {
+	if p.OutDir == "" {
+		return DefaultOutDir
+	}
+	return p.OutDir
+}
+
+// OutPath returns the path of an output file named as in ImageOutputComplete, with pgm images given their extension.
+func (p Params) OutPath(filename string) string {
+	if filepath.Ext(filename) == "" {
+		filename += ".pgm"
+	}
+	return filepath.Join(p.outDir(), filename)
+}
+
+// outName returns the name of the output file for the given turn, without an extension.
+func (p Params) outName(turn int) string {
+	template := p.OutName
+	if template == "" {
+		template = DefaultOutName
+	}
+
+	name := fmt.Sprintf("%dx%d", p.ImageWidth, p.ImageHeight)
+	if p.Pattern != "" {
+		name = p.Pattern
+	} else if p.Input != "" {
+		name = p.Input
+	}
+	name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+
+	return strings.NewReplacer(
+		"{w}", strconv.Itoa(p.ImageWidth),
+		"{h}", strconv.Itoa(p.ImageHeight),
+		"{turn}", strconv.Itoa(turn),
+		"{name}", name,
+	).Replace(template)
+}
+
+// IsImage reports whether path is a Netpbm image or a snapshot rather than a pattern file, judging by its extension.
+func IsImage(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pbm", ".pgm", ".ppm", ".pnm", ".snap":
+		return true
+	}
+	return false
+}
+
+func isSnapshot(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".snap"
+}
+
+// ReadSize reads the width and height of the image or pattern at path from its header.
+// Patterns without a header, such as .cells and Life files, are as large as their bounding box.
+func ReadSize(path string) (width, height int, err error) {
+	if isSnapshot(path) {
+		s, err := util.ReadSnapshotHeader(path)
+		return s.Width, s.Height, err
+	}
+	if IsImage(path) {
+		return readPnmSize(path)
+	}
+	pat, err := readPatternFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	return pat.width, pat.height, nil
+}
+
+var savedName = regexp.MustCompile(`^(\d+)x(\d+)x(\d+)$`)
+
+// ReadTurn reads the turn of the world saved at path, from the header of a snapshot.
+// An image only gives its turn by a name of the default form {w}x{h}x{turn}, as in 512x512x100.pgm,
+// with the width and height of the image. Any other name is an error rather than a guess.
+func ReadTurn(path string) (int, error) {
+	if isSnapshot(path) {
+		s, err := util.ReadSnapshotHeader(path)
+		return s.Turn, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	match := savedName.FindStringSubmatch(name)
+	if match == nil {
+		return 0, fmt.Errorf("the name of %v is not of the form {w}x{h}x{turn}", path)
+	}
+	width, height, err := ReadSize(path)
+	if err != nil {
+		return 0, err
+	}
+	if match[1] != strconv.Itoa(width) || match[2] != strconv.Itoa(height) {
+		return 0, fmt.Errorf("the name of %v does not match its size of %vx%v", path, width, height)
+	}
+	return strconv.Atoi(match[3])
+}
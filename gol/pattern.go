@@ -0,0 +1,122 @@
+package gol
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// pattern is a set of alive cells inside a width x height bounding box, as read from a pattern file.
+// Formats that give cells as coordinates also set origin, the position of (0, 0) inside the box,
+// which is placed at the centre of the board when the pattern is centred.
+type pattern struct {
+	width, height int
+	cells         []util.Cell
+	origin        util.Cell
+	hasOrigin     bool
+}
+
+// checkRule rejects patterns written for any rule other than Conway's B3/S23, which is the only one we run.
+// A Golly topology suffix such as ":T64,64" is ignored.
+func checkRule(rule string) error {
+	name := strings.ToUpper(strings.TrimSpace(strings.SplitN(rule, ":", 2)[0]))
+	switch name {
+	case "", "B3/S23", "23/3", "LIFE", "CONWAY'S LIFE":
+		return nil
+	}
+	return fmt.Errorf("unsupported rule %v", rule)
+}
+
+// readPatternFile reads a pattern from path, choosing the format by the file extension.
+func readPatternFile(path string) (pattern, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return pattern{}, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".rle":
+		return readRle(file)
+	case ".cells":
+		return readCells(file)
+	case ".lif", ".life":
+		return readLife(file)
+	}
+	return pattern{}, fmt.Errorf("%v is not a supported pattern format", filepath.Ext(path))
+}
+
+// place puts the pattern on an empty width x height world, either centred or with its top left corner at offset.
+func (pat pattern) place(width, height int, offset util.Cell, centre bool) ([][]byte, error) {
+	if pat.width > width || pat.height > height {
+		return nil, fmt.Errorf(
+			"%vx%v pattern does not fit on a %vx%v board",
+			pat.width,
+			pat.height,
+			width,
+			height,
+		)
+	}
+	if centre && pat.hasOrigin {
+		offset = util.Cell{X: width/2 - pat.origin.X, Y: height/2 - pat.origin.Y}
+	} else if centre {
+		offset = util.Cell{X: (width - pat.width) / 2, Y: (height - pat.height) / 2}
+	}
+
+	world := make([][]byte, height)
+	for i := range world {
+		world[i] = make([]byte, width)
+	}
+	for _, cell := range pat.cells {
+		x := ((cell.X+offset.X)%width + width) % width
+		y := ((cell.Y+offset.Y)%height + height) % height
+		world[y][x] = 255
+	}
+	return world, nil
+}
+
+// fromCoordinates builds a pattern from cells given relative to an origin, moving them into their bounding box.
+func fromCoordinates(cells []util.Cell) pattern {
+	pat := pattern{cells: cells, hasOrigin: true}
+	if len(cells) == 0 {
+		return pat
+	}
+	min, max := cells[0], cells[0]
+	for _, cell := range cells {
+		if cell.X < min.X {
+			min.X = cell.X
+		}
+		if cell.Y < min.Y {
+			min.Y = cell.Y
+		}
+		if cell.X > max.X {
+			max.X = cell.X
+		}
+		if cell.Y > max.Y {
+			max.Y = cell.Y
+		}
+	}
+	for i := range pat.cells {
+		pat.cells[i].X -= min.X
+		pat.cells[i].Y -= min.Y
+	}
+	pat.width = max.X - min.X + 1
+	pat.height = max.Y - min.Y + 1
+	pat.origin = util.Cell{X: -min.X, Y: -min.Y}
+	return pat
+}
+
+// grid returns the pattern as rows of cells, the way edits are sent to the broker.
+func (pat pattern) grid() [][]byte {
+	grid := make([][]byte, pat.height)
+	for i := range grid {
+		grid[i] = make([]byte, pat.width)
+	}
+	for _, cell := range pat.cells {
+		grid[cell.Y][cell.X] = 255
+	}
+	return grid
+}
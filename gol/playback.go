@@ -0,0 +1,222 @@
+package gol
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// EventLog is a recorded run opened for playback, as the sequence of frames the front end showed while it ran.
+// A frame ends with each TurnComplete or StateChange, and holds the cells flipped since the frame before.
+// Flipping them again steps back, and the keyframes of the whole world in the log let playback jump to any frame quickly.
+// Only where each frame and keyframe is in the log is kept in memory, and they are read from the log when needed.
+type EventLog struct {
+	Params    Params
+	file      *os.File
+	frames    []logFrame
+	keyframes []logKeyframe
+}
+
+// logFrame is the turn shown by a frame and the bytes of the log from the end of the frame before to its end.
+type logFrame struct {
+	turn       int
+	start, end int64
+}
+
+// logKeyframe is where the keyframe of the world shown at the end of a frame is in the log.
+type logKeyframe struct {
+	frame  int
+	offset int64
+}
+
+// LoadEventLog opens the event log at path, reading through it once to check it and to find its frames and keyframes.
+// The log is kept open until the EventLog is closed.
+func LoadEventLog(path string) (*EventLog, error) {
+	file, scanner, p, _, err := openLog(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := indexLog(path, file, scanner, p)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// indexLog reads through the events of a log opened by openLog, finding its frames and keyframes.
+func indexLog(path string, file *os.File, scanner *logScanner, p Params) (*EventLog, error) {
+	l := &EventLog{Params: p, file: file}
+	start := scanner.offset
+	flipped := 0
+	flip := func(cell util.Cell) error {
+		if cell.X < 0 || cell.Y < 0 || cell.X >= p.ImageWidth || cell.Y >= p.ImageHeight {
+			return fmt.Errorf("cell %v is outside the %vx%v board", cell, p.ImageWidth, p.ImageHeight)
+		}
+		flipped++
+		return nil
+	}
+	// end closes the frame at turn, unless nothing has changed since the last one.
+	end := func(turn int) {
+		if len(l.frames) > 0 && flipped == 0 && l.frames[len(l.frames)-1].turn == turn {
+			return
+		}
+		l.frames = append(l.frames, logFrame{turn, start, scanner.offset})
+		start = scanner.offset
+		flipped = 0
+	}
+
+	// at is the offset of the line scanned.
+	for line, at := 2, scanner.offset; scanner.Scan(); line, at = line+1, scanner.offset {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%v line %v: %v", path, line, err)
+		}
+		if r.Type == keyframeRecord {
+			var k keyframe
+			if err := json.Unmarshal(r.Event, &k); err != nil {
+				return nil, fmt.Errorf("%v line %v: %v", path, line, err)
+			}
+			end(k.CompletedTurns)
+			l.keyframes = append(l.keyframes, logKeyframe{len(l.frames) - 1, at})
+			continue
+		}
+		event, err := decodeEvent(r.Type, r.Event)
+		if err != nil {
+			return nil, fmt.Errorf("%v line %v: %v", path, line, err)
+		}
+		switch e := event.(type) {
+		case CellFlipped:
+			err = flip(e.Cell)
+		case CellsFlipped:
+			for _, cell := range e.Cells {
+				if err = flip(cell); err != nil {
+					break
+				}
+			}
+		case TurnComplete, StateChange:
+			end(event.GetCompletedTurns())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v line %v: %v", path, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if flipped > 0 || len(l.frames) == 0 {
+		turn := p.StartTurn
+		if len(l.frames) > 0 {
+			turn = l.frames[len(l.frames)-1].turn
+		}
+		end(turn)
+	}
+	return l, nil
+}
+
+// Close closes the log.
+func (l *EventLog) Close() error {
+	return l.file.Close()
+}
+
+// read calls each with every record of the log from offset start up to end.
+// The log was checked when it was loaded, so failing to read it again is not expected and panics.
+func (l *EventLog) read(start, end int64, each func(r record)) {
+	_, err := l.file.Seek(start, io.SeekStart)
+	util.Check(err)
+	scanner := newLogScanner(l.file, start)
+	for scanner.offset < end && scanner.Scan() {
+		var r record
+		util.Check(json.Unmarshal(scanner.Bytes(), &r))
+		each(r)
+	}
+	util.Check(scanner.Err())
+}
+
+// flips calls each with every cell flipped from offset start up to end.
+func (l *EventLog) flips(start, end int64, each func(cell util.Cell)) {
+	l.read(start, end, func(r record) {
+		if r.Type == keyframeRecord {
+			return
+		}
+		event, err := decodeEvent(r.Type, r.Event)
+		util.Check(err)
+		switch e := event.(type) {
+		case CellFlipped:
+			each(e.Cell)
+		case CellsFlipped:
+			for _, cell := range e.Cells {
+				each(cell)
+			}
+		}
+	})
+}
+
+// Frames returns the number of frames in the log. There is always at least one, showing the world the run started from.
+func (l *EventLog) Frames() int {
+	return len(l.frames)
+}
+
+// Turn returns the turn shown by a frame.
+func (l *EventLog) Turn(frame int) int {
+	return l.frames[frame].turn
+}
+
+// Flips returns the cells that flip between the frame before and this one, in either direction.
+func (l *EventLog) Flips(frame int) []util.Cell {
+	var flips []util.Cell
+	l.flips(l.frames[frame].start, l.frames[frame].end, func(cell util.Cell) {
+		flips = append(flips, cell)
+	})
+	return flips
+}
+
+// World returns the world shown by a frame, built up from the keyframe before it,
+// or from the empty world the log starts from when there is none.
+func (l *EventLog) World(frame int) [][]byte {
+	p := l.Params
+	world := make([][]byte, p.ImageHeight)
+	for i := range world {
+		world[i] = make([]byte, p.ImageWidth)
+	}
+	from := 0
+	if k := sort.Search(len(l.keyframes), func(i int) bool { return l.keyframes[i].frame > frame }) - 1; k >= 0 {
+		var packed keyframe
+		l.read(l.keyframes[k].offset, l.keyframes[k].offset+1, func(r record) {
+			util.Check(json.Unmarshal(r.Event, &packed))
+		})
+		world = util.UnpackWorld(packed.World, p.ImageWidth, p.ImageHeight)
+		from = l.keyframes[k].frame + 1
+	}
+	if from <= frame {
+		l.flips(l.frames[from].start, l.frames[frame].end, func(cell util.Cell) {
+			world[cell.Y][cell.X] ^= 0xFF
+		})
+	}
+	return world
+}
+
+// FrameAt returns the first frame showing turn, or the frame with the closest turn when no frame shows it.
+func (l *EventLog) FrameAt(turn int) int {
+	best := 0
+	for f, frame := range l.frames {
+		if frame.turn == turn {
+			return f
+		}
+		if abs(frame.turn-turn) < abs(l.frames[best].turn-turn) {
+			best = f
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,307 @@
+package gol
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// pnmHeader is the header of a Netpbm image.
+// Bitmaps (P1 and P4) have no maxval, as their pixels are single bits.
+type pnmHeader struct {
+	magic         string
+	width, height int
+	maxval        int
+}
+
+// pnmReader reads the tokens of a Netpbm image, skipping whitespace and comments.
+type pnmReader struct {
+	r *bufio.Reader
+}
+
+// skip discards whitespace and comments up to the next token.
+func (pr pnmReader) skip() error {
+	for {
+		b, err := pr.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case b == '#':
+			if _, err := pr.r.ReadString('\n'); err != nil {
+				return err
+			}
+		case !isPnmSpace(b):
+			return pr.r.UnreadByte()
+		}
+	}
+}
+
+// token reads the next token, consuming the single whitespace byte that ends it.
+func (pr pnmReader) token() (string, error) {
+	if err := pr.skip(); err != nil {
+		return "", err
+	}
+	var token []byte
+	for {
+		b, err := pr.r.ReadByte()
+		if err == io.EOF && len(token) > 0 {
+			return string(token), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if isPnmSpace(b) {
+			return string(token), nil
+		}
+		if b == '#' {
+			if err := pr.r.UnreadByte(); err != nil {
+				return "", err
+			}
+			return string(token), nil
+		}
+		token = append(token, b)
+	}
+}
+
+func (pr pnmReader) int() (int, error) {
+	token, err := pr.token()
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(token)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a number, got %q", token)
+	}
+	return n, nil
+}
+
+func isPnmSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+func (pr pnmReader) header() (pnmHeader, error) {
+	var h pnmHeader
+	var err error
+	if h.magic, err = pr.token(); err != nil {
+		return h, fmt.Errorf("not a pnm image: %v", err)
+	}
+	switch h.magic {
+	case "P1", "P2", "P3", "P4", "P5", "P6":
+	default:
+		return h, fmt.Errorf("not a pnm image: unknown magic number %q", h.magic)
+	}
+	if h.width, err = pr.int(); err != nil {
+		return h, fmt.Errorf("pnm width: %v", err)
+	}
+	if h.height, err = pr.int(); err != nil {
+		return h, fmt.Errorf("pnm height: %v", err)
+	}
+	if err = util.CheckWorldSize(h.width, h.height); err != nil {
+		return h, fmt.Errorf("pnm image: %v", err)
+	}
+	h.maxval = 1
+	if h.magic != "P1" && h.magic != "P4" {
+		if h.maxval, err = pr.int(); err != nil {
+			return h, fmt.Errorf("pnm maxval: %v", err)
+		}
+		if h.maxval < 1 || h.maxval > 65535 {
+			return h, fmt.Errorf("pnm maxval %v is out of range", h.maxval)
+		}
+	}
+	return h, nil
+}
+
+// sample reads the next sample of a greymap or pixmap, as text or as one or two bytes depending on the maxval.
+func (pr pnmReader) sample(h pnmHeader) (int, error) {
+	if h.magic == "P2" || h.magic == "P3" {
+		return pr.int()
+	}
+	b, err := pr.r.ReadByte()
+	if err != nil || h.maxval < 256 {
+		return int(b), err
+	}
+	lo, err := pr.r.ReadByte()
+	return int(b)<<8 | int(lo), err
+}
+
+// readPnm reads a Netpbm image of any of the formats P1 to P6.
+// Set bits of bitmaps are alive, as are greys and colours brighter than half of maxval,
+// where colours are converted to grey by their luminance.
+func readPnm(r io.Reader) ([][]byte, error) {
+	pr := pnmReader{bufio.NewReaderSize(r, ioBufferSize)}
+	h, err := pr.header()
+	if err != nil {
+		return nil, err
+	}
+
+	world := make([][]byte, h.height)
+	for y := range world {
+		world[y] = make([]byte, h.width)
+	}
+	threshold := h.maxval / 2
+
+	for y := 0; y < h.height; y++ {
+		switch h.magic {
+		case "P1":
+			for x := 0; x < h.width; x++ {
+				// Bits need not be separated by whitespace.
+				if err = pr.skip(); err != nil {
+					break
+				}
+				var b byte
+				if b, err = pr.r.ReadByte(); err != nil {
+					break
+				}
+				if b != '0' && b != '1' {
+					return nil, fmt.Errorf("pbm row %v: unexpected %q", y, b)
+				}
+				if b == '1' {
+					world[y][x] = 255
+				}
+			}
+		case "P4":
+			row := make([]byte, (h.width+7)/8)
+			if _, err = io.ReadFull(pr.r, row); err != nil {
+				break
+			}
+			for x := 0; x < h.width; x++ {
+				if row[x/8]&(0x80>>(x%8)) != 0 {
+					world[y][x] = 255
+				}
+			}
+		case "P5":
+			if h.maxval < 256 {
+				// Single byte greys are read and thresholded a whole row at a time.
+				if _, err = io.ReadFull(pr.r, world[y]); err != nil {
+					break
+				}
+				for x, grey := range world[y] {
+					world[y][x] = 0
+					if int(grey) > threshold {
+						world[y][x] = 255
+					}
+				}
+				break
+			}
+			fallthrough
+		case "P2":
+			for x := 0; x < h.width; x++ {
+				var grey int
+				if grey, err = pr.sample(h); err != nil {
+					break
+				}
+				if grey > threshold {
+					world[y][x] = 255
+				}
+			}
+		case "P3", "P6":
+			for x := 0; x < h.width; x++ {
+				var rgb [3]int
+				for i := range rgb {
+					if rgb[i], err = pr.sample(h); err != nil {
+						break
+					}
+				}
+				if err != nil {
+					break
+				}
+				if 299*rgb[0]+587*rgb[1]+114*rgb[2] > 1000*threshold {
+					world[y][x] = 255
+				}
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errors.New("pnm image data is truncated")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return world, nil
+}
+
+// readPnmSize reads the width and height from the header of the image at path.
+func readPnmSize(path string) (width, height int, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	h, err := pnmReader{bufio.NewReader(file)}.header()
+	return h.width, h.height, err
+}
+
+// readPnmFile reads the image at path.
+func readPnmFile(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readPnm(file)
+}
+
+func writePnmHeader(out *bufio.Writer, magic string, world [][]byte, maxval int, comment string) {
+	height := len(world)
+	width := 0
+	if height > 0 {
+		width = len(world[0])
+	}
+	_, _ = fmt.Fprintf(out, "%v\n", magic)
+	if comment != "" {
+		_, _ = fmt.Fprintf(out, "# %v\n", comment)
+	}
+	_, _ = fmt.Fprintf(out, "%v %v\n", width, height)
+	if maxval > 0 {
+		_, _ = fmt.Fprintf(out, "%v\n", maxval)
+	}
+}
+
+// writePgm writes the world as a binary pgm image.
+// Each of the Netpbm writers puts the comment, if any, in the header.
+func writePgm(w io.Writer, world [][]byte, comment string) error {
+	out := bufio.NewWriter(w)
+	writePnmHeader(out, "P5", world, 255, comment)
+	for _, row := range world {
+		_, _ = out.Write(row)
+	}
+	return out.Flush()
+}
+
+// writePbm writes the world as a binary pbm bitmap, with alive cells as set bits.
+func writePbm(w io.Writer, world [][]byte, comment string) error {
+	out := bufio.NewWriter(w)
+	writePnmHeader(out, "P4", world, 0, comment)
+	for _, row := range world {
+		packed := make([]byte, (len(row)+7)/8)
+		for x, cell := range row {
+			if cell == 255 {
+				packed[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		_, _ = out.Write(packed)
+	}
+	return out.Flush()
+}
+
+// writePpm writes the world as a binary ppm pixmap, with alive cells in white.
+func writePpm(w io.Writer, world [][]byte, comment string) error {
+	out := bufio.NewWriter(w)
+	writePnmHeader(out, "P6", world, 255, comment)
+	var pixels []byte
+	for _, row := range world {
+		pixels = pixels[:0]
+		for _, cell := range row {
+			pixels = append(pixels, cell, cell, cell)
+		}
+		_, _ = out.Write(pixels)
+	}
+	return out.Flush()
+}
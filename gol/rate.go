@@ -0,0 +1,29 @@
+package gol
+
+// TurnRates are the limits on turns per second that the + and - keys step through.
+// Stepping up from the fastest leaves the run unlimited, and stepping down from unlimited limits it to the fastest.
+var TurnRates = []int{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+
+// nextTurnRate returns the limit after rate, one step up or down TurnRates. A rate of 0 is unlimited.
+func nextTurnRate(rate int, faster bool) int {
+	if faster {
+		if rate == 0 {
+			return 0
+		}
+		for _, step := range TurnRates {
+			if step > rate {
+				return step
+			}
+		}
+		return 0
+	}
+	if rate == 0 {
+		return TurnRates[len(TurnRates)-1]
+	}
+	for i := len(TurnRates) - 1; i >= 0; i-- {
+		if TurnRates[i] < rate {
+			return TurnRates[i]
+		}
+	}
+	return TurnRates[0]
+}
@@ -0,0 +1,226 @@
+package gol
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// record is a single line of an event log.
+// Type is the name of the event type, and the first line of every log holds the Params of the run.
+type record struct {
+	Type  string          `json:"type"`
+	Time  time.Time       `json:"time"`
+	Event json.RawMessage `json:"event"`
+}
+
+const (
+	paramsRecord   = "Params"
+	keyframeRecord = "Keyframe"
+)
+
+// keyframe is the whole world as displayed at the end of a turn, packed by util.PackWorld.
+// Keyframes are written between events so that playback can seek without going through every flip before.
+type keyframe struct {
+	CompletedTurns int
+	World          []byte
+}
+
+// Recorder writes events to a JSON-lines event log, one event per line.
+// It keeps its own copy of the world from the flips it records, and writes it as a keyframe
+// after any turn by which more cells have flipped since the last keyframe than there are on the board.
+type Recorder struct {
+	file          *os.File
+	writer        *bufio.Writer
+	width, height int
+	world         [][]byte
+	// flipped counts the cells flipped since the last keyframe.
+	flipped int
+}
+
+// NewRecorder creates the event log at path and writes the Params of the run to it.
+func NewRecorder(path string, p Params) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	world := make([][]byte, p.ImageHeight)
+	for i := range world {
+		world[i] = make([]byte, p.ImageWidth)
+	}
+	r := &Recorder{file: file, writer: bufio.NewWriter(file), width: p.ImageWidth, height: p.ImageHeight, world: world}
+	if err := r.write(paramsRecord, p); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) write(tag string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(record{tag, time.Now(), data})
+	if err != nil {
+		return err
+	}
+	_, err = r.writer.Write(append(line, '\n'))
+	return err
+}
+
+// Write appends a single event to the log, followed by a keyframe if one is due.
+func (r *Recorder) Write(event Event) error {
+	switch e := event.(type) {
+	case CellFlipped:
+		r.world[e.Cell.Y][e.Cell.X] ^= 0xFF
+		r.flipped++
+	case CellsFlipped:
+		for _, cell := range e.Cells {
+			r.world[cell.Y][cell.X] ^= 0xFF
+		}
+		r.flipped += len(e.Cells)
+	}
+	// Events of a type that was not registered could be written, but not read back.
+	name := reflect.TypeOf(event).Name()
+	if _, ok := eventTypes[name]; !ok {
+		return fmt.Errorf("event type %v is not registered", name)
+	}
+	if err := r.write(name, event); err != nil {
+		return err
+	}
+	if _, ok := event.(TurnComplete); ok && r.flipped > 0 && r.flipped >= r.width*r.height {
+		r.flipped = 0
+		return r.write(keyframeRecord, keyframe{event.GetCompletedTurns(), util.PackWorld(r.world, r.width, r.height)})
+	}
+	return nil
+}
+
+// Close flushes the log to disk.
+func (r *Recorder) Close() error {
+	err := r.writer.Flush()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Forward records every event received from in and passes it on to out.
+// The log is closed, and so is out, once in is closed.
+func (r *Recorder) Forward(in <-chan Event, out chan<- Event) {
+	for event := range in {
+		if err := r.Write(event); err != nil {
+			log.Printf("[Record] %v %v", util.Red("ERROR"), err)
+		}
+		out <- event
+	}
+	if err := r.Close(); err != nil {
+		log.Printf("[Record] %v %v", util.Red("ERROR"), err)
+	}
+	close(out)
+}
+
+// decodeEvent turns the JSON of a recorded event back into an Event of the type named by tag.
+func decodeEvent(tag string, data []byte) (Event, error) {
+	newEvent, ok := eventTypes[tag]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", tag)
+	}
+	event := newEvent()
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(event).Elem().Interface().(Event), nil
+}
+
+// logScanner scans the lines of an event log, keeping the offset in the file of the end of the line last scanned.
+type logScanner struct {
+	*bufio.Scanner
+	offset int64
+}
+
+// newLogScanner scans the lines of r, which starts at offset in the log.
+func newLogScanner(r io.Reader, offset int64) *logScanner {
+	s := &logScanner{Scanner: bufio.NewScanner(r), offset: offset}
+	s.Buffer(nil, 1<<30)
+	s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		s.offset += int64(advance)
+		return advance, token, err
+	})
+	return s
+}
+
+// openLog opens the event log at path and reads the Params of the recorded run from its first line.
+// The scanner is left at the first event, and the time the log was started is returned with the Params.
+func openLog(path string) (*os.File, *logScanner, Params, time.Time, error) {
+	var p Params
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, p, time.Time{}, err
+	}
+	scanner := newLogScanner(file, 0)
+
+	var header record
+	if !scanner.Scan() {
+		file.Close()
+		return nil, nil, p, time.Time{}, fmt.Errorf("%v is empty", path)
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Type != paramsRecord {
+		file.Close()
+		return nil, nil, p, time.Time{}, fmt.Errorf("%v is not an event log", path)
+	}
+	if err := json.Unmarshal(header.Event, &p); err != nil {
+		file.Close()
+		return nil, nil, p, time.Time{}, err
+	}
+	return file, scanner, p, header.Time, nil
+}
+
+// Replay reads the event log at path and sends its events on the returned channel, which is closed at the end of the log.
+// The Params of the recorded run are returned as well.
+// With realtime set, the original time between events is reproduced, otherwise events are sent as fast as they are consumed.
+func Replay(path string, realtime bool) (Params, <-chan Event, error) {
+	file, scanner, p, last, err := openLog(path)
+	if err != nil {
+		return p, nil, err
+	}
+
+	events := make(chan Event, 1000)
+	go func() {
+		defer file.Close()
+		defer close(events)
+		for line := 2; scanner.Scan(); line++ {
+			var r record
+			err := json.Unmarshal(scanner.Bytes(), &r)
+			if err != nil {
+				log.Printf("[Replay] %v %v line %v: %v", util.Red("ERROR"), path, line, err)
+				return
+			}
+			if r.Type == keyframeRecord {
+				continue
+			}
+			event, err := decodeEvent(r.Type, r.Event)
+			if err != nil {
+				log.Printf("[Replay] %v %v line %v: %v", util.Red("ERROR"), path, line, err)
+				return
+			}
+			if realtime {
+				time.Sleep(r.Time.Sub(last))
+				last = r.Time
+			}
+			events <- event
+		}
+		if err := scanner.Err(); err != nil {
+			log.Printf("[Replay] %v %v", util.Red("ERROR"), err)
+		}
+	}()
+	return p, events, nil
+}
@@ -0,0 +1,166 @@
+package gol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// readRle parses a pattern in Golly's run length encoded format.
+// Lines starting with # are comments, the header gives the size of the pattern and its rule,
+// and the runs of dead (b) and alive (o) cells may span any number of lines up to the closing !.
+func readRle(r io.Reader) (pattern, error) {
+	var pat pattern
+	scanner := bufio.NewScanner(r)
+	header := false
+	x, y, count := 0, 0, 0
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if !header {
+			header = true
+			for _, field := range strings.Split(text, ",") {
+				kv := strings.SplitN(field, "=", 2)
+				if len(kv) != 2 {
+					return pat, fmt.Errorf("rle line %v: malformed header %q", line, text)
+				}
+				key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+				var err error
+				switch key {
+				case "x":
+					pat.width, err = strconv.Atoi(value)
+				case "y":
+					pat.height, err = strconv.Atoi(value)
+				case "rule":
+					err = checkRule(value)
+				}
+				if err != nil {
+					return pat, fmt.Errorf("rle line %v: %v", line, err)
+				}
+			}
+			continue
+		}
+
+		for _, ch := range text {
+			switch {
+			case ch >= '0' && ch <= '9':
+				count = count*10 + int(ch-'0')
+				continue
+			case ch == ' ' || ch == '\t':
+				continue
+			}
+
+			run := count
+			if run == 0 {
+				run = 1
+			}
+			count = 0
+			switch ch {
+			case 'b', '.':
+				x += run
+			case 'o':
+				for i := 0; i < run; i++ {
+					pat.cells = append(pat.cells, util.Cell{X: x + i, Y: y})
+				}
+				x += run
+			case '$':
+				x = 0
+				y += run
+			case '!':
+				return pat, pat.check()
+			default:
+				return pat, fmt.Errorf("rle line %v: unexpected %q", line, ch)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return pat, err
+	}
+	if !header {
+		return pat, fmt.Errorf("rle: missing header")
+	}
+	return pat, pat.check()
+}
+
+// check makes sure that every cell lies within the size given in the header.
+func (pat pattern) check() error {
+	for _, cell := range pat.cells {
+		if cell.X >= pat.width || cell.Y >= pat.height {
+			return fmt.Errorf("cell (%v, %v) is outside the %vx%v pattern", cell.X, cell.Y, pat.width, pat.height)
+		}
+	}
+	return nil
+}
+
+// writeRle writes the whole world in Golly's run length encoded format, keeping lines under 70 characters.
+func writeRle(w io.Writer, world [][]byte, comment string) error {
+	height := len(world)
+	width := 0
+	if height > 0 {
+		width = len(world[0])
+	}
+	out := bufio.NewWriter(w)
+	if comment != "" {
+		_, _ = fmt.Fprintf(out, "#C %v\n", comment)
+	}
+	_, _ = fmt.Fprintf(out, "x = %v, y = %v, rule = B3/S23\n", width, height)
+
+	lineLength := 0
+	emit := func(run int, tag byte) {
+		token := string(tag)
+		if run > 1 {
+			token = strconv.Itoa(run) + token
+		}
+		if lineLength+len(token) > 70 {
+			_ = out.WriteByte('\n')
+			lineLength = 0
+		}
+		_, _ = out.WriteString(token)
+		lineLength += len(token)
+	}
+
+	emptyRows := 0
+	started := false
+	for _, row := range world {
+		// Trailing dead cells of a row are implied by the end of row marker.
+		end := len(row)
+		for end > 0 && row[end-1] != 255 {
+			end--
+		}
+		if end == 0 {
+			emptyRows++
+			continue
+		}
+		if started {
+			emit(emptyRows+1, '$')
+		} else if emptyRows > 0 {
+			emit(emptyRows, '$')
+		}
+		started = true
+		emptyRows = 0
+
+		for x := 0; x < end; {
+			run := 1
+			for x+run < end && (row[x+run] == 255) == (row[x] == 255) {
+				run++
+			}
+			if row[x] == 255 {
+				emit(run, 'o')
+			} else {
+				emit(run, 'b')
+			}
+			x += run
+		}
+	}
+	emit(1, '!')
+	_ = out.WriteByte('\n')
+	return out.Flush()
+}
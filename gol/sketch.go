@@ -0,0 +1,151 @@
+package gol
+
+import (
+	"image"
+	"math"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Sketch is a board edited by hand while a run is paused, as draw mode does.
+// Edits are only made to the sketch until Edits is called, which gives the cells to send to the engine all at once.
+// Cells are set, selected and pasted by the coordinates of the board, wrapping around its edges as pasting does in the engine.
+type Sketch struct {
+	Width, Height int
+	// Selection is the rectangle of cells the selection methods act on, always on the board.
+	Selection image.Rectangle
+	// base is the board as the engine has it, and board the board with the edits made to it.
+	base, board [][]byte
+	flip        func(x, y int)
+}
+
+// NewSketch starts a sketch of world, calling flip for every cell whose value is changed.
+func NewSketch(world [][]byte, flip func(x, y int)) *Sketch {
+	s := &Sketch{Height: len(world), flip: flip}
+	if len(world) > 0 {
+		s.Width = len(world[0])
+	}
+	s.base = make([][]byte, s.Height)
+	s.board = make([][]byte, s.Height)
+	for y := range world {
+		s.base[y] = append([]byte(nil), world[y]...)
+		s.board[y] = append([]byte(nil), world[y]...)
+	}
+	return s
+}
+
+// Alive reports whether the cell at (x, y) is alive in the sketch.
+func (s *Sketch) Alive(x, y int) bool {
+	return s.board[y][x] == 0xFF
+}
+
+// Set sets a cell of the sketch to value.
+func (s *Sketch) Set(x, y int, value byte) {
+	if s.board[y][x] != value {
+		s.board[y][x] = value
+		s.flip(x, y)
+	}
+}
+
+// Line sets the cells on the line from one cell to another to value, except the first.
+func (s *Sketch) Line(from, to util.Cell, value byte) {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	steps := abs(dx)
+	if abs(dy) > steps {
+		steps = abs(dy)
+	}
+	for i := 1; i <= steps; i++ {
+		x := from.X + int(math.Round(float64(dx*i)/float64(steps)))
+		y := from.Y + int(math.Round(float64(dy*i)/float64(steps)))
+		s.Set(x, y, value)
+	}
+}
+
+// Select selects the cells of r that are on the board.
+func (s *Sketch) Select(r image.Rectangle) {
+	s.Selection = r.Intersect(image.Rect(0, 0, s.Width, s.Height))
+}
+
+// Selected returns a copy of the cells selected.
+func (s *Sketch) Selected() [][]byte {
+	r := s.Selection
+	grid := make([][]byte, r.Dy())
+	for y := range grid {
+		grid[y] = append([]byte(nil), s.board[r.Min.Y+y][r.Min.X:r.Max.X]...)
+	}
+	return grid
+}
+
+// Fill sets every selected cell to a value given by value.
+func (s *Sketch) Fill(value func() byte) {
+	r := s.Selection
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			s.Set(x, y, value())
+		}
+	}
+}
+
+// Put writes grid to the sketch with its top left corner at at, wrapping around the edges.
+// The cells it covers on the board are selected.
+func (s *Sketch) Put(grid [][]byte, at util.Cell) {
+	for y := range grid {
+		for x := range grid[y] {
+			s.Set(mod(at.X+x, s.Width), mod(at.Y+y, s.Height), grid[y][x])
+		}
+	}
+	if len(grid) > 0 {
+		s.Select(image.Rect(at.X, at.Y, at.X+len(grid[0]), at.Y+len(grid)))
+	}
+}
+
+// Rotate turns the selected cells a quarter turn clockwise about the top left corner of the selection.
+func (s *Sketch) Rotate() {
+	if s.Selection.Empty() {
+		return
+	}
+	grid := s.Selected()
+	rotated := make([][]byte, len(grid[0]))
+	for y := range rotated {
+		rotated[y] = make([]byte, len(grid))
+		for x := range rotated[y] {
+			rotated[y][x] = grid[len(grid)-1-x][y]
+		}
+	}
+	s.Fill(func() byte { return 0 })
+	s.Put(rotated, util.Cell{X: s.Selection.Min.X, Y: s.Selection.Min.Y})
+}
+
+// Edits returns the cells edited as those to set and clear on the engine's board.
+func (s *Sketch) Edits() (set, clear []util.Cell) {
+	for y := range s.board {
+		for x := range s.board[y] {
+			if s.board[y][x] == s.base[y][x] {
+				continue
+			}
+			if s.board[y][x] == 0xFF {
+				set = append(set, util.Cell{X: x, Y: y})
+			} else {
+				clear = append(clear, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return set, clear
+}
+
+// CatchesUp reports whether an event is the engine catching up with cells edited at turn.
+// It sends flips from the board it last sent, from before the cells were edited, so they are taken back off the display first.
+// A turn complete without flips means the engine's board is that board, unless it is still the turn they were edited at.
+func CatchesUp(event Event, turn int) bool {
+	switch event.(type) {
+	case CellFlipped, CellsFlipped:
+		return true
+	case TurnComplete:
+		return event.GetCompletedTurns() != turn
+	}
+	return false
+}
+
+func mod(n, m int) int {
+	return (n%m + m) % m
+}
@@ -0,0 +1,89 @@
+package gol
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Symmetries lists the symmetries a soup can be generated with.
+// C2 is symmetric under a half turn, C4 under a quarter turn and D8 under quarter turns and reflections as well.
+var Symmetries = []string{"none", "C2", "C4", "D8"}
+
+// source describes how the world was generated, so that a soup can be made again exactly.
+// A world read from a snapshot keeps the source of the snapshot, so a resumed soup still records its seed and density.
+// It is empty for other worlds read from a file.
+func (p Params) source() string {
+	if !p.Soup {
+		return p.origin
+	}
+	return fmt.Sprintf("soup seed=%v density=%v symmetry=%v", p.Seed, p.Density, p.symmetry())
+}
+
+func (p Params) symmetry() string {
+	if p.Symmetry == "" {
+		return "none"
+	}
+	return p.Symmetry
+}
+
+// generateSoup fills a width x height world with random cells, each alive with the given density.
+// With a symmetry, every cell is decided once for its whole orbit under the symmetry, so the same seed always gives the same soup.
+func generateSoup(width, height int, seed int64, density float64, symmetry string) ([][]byte, error) {
+	var images func(x, y int) []cellImage
+	n := width - 1
+	m := height - 1
+	switch symmetry {
+	case "", "none":
+		images = func(x, y int) []cellImage {
+			return []cellImage{{x, y}}
+		}
+	case "C2":
+		images = func(x, y int) []cellImage {
+			return []cellImage{{x, y}, {n - x, m - y}}
+		}
+	case "C4", "D8":
+		if width != height {
+			return nil, fmt.Errorf("%v symmetry needs a square board, not %vx%v", symmetry, width, height)
+		}
+		images = func(x, y int) []cellImage {
+			return []cellImage{{x, y}, {n - y, x}, {n - x, n - y}, {y, n - x}}
+		}
+		if symmetry == "D8" {
+			rotations := images
+			images = func(x, y int) []cellImage {
+				return append(rotations(x, y), rotations(n-x, y)...)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown symmetry %v", symmetry)
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	world := make([][]byte, height)
+	decided := make([][]bool, height)
+	for y := range world {
+		world[y] = make([]byte, width)
+		decided[y] = make([]bool, width)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if decided[y][x] {
+				continue
+			}
+			var cell byte
+			if random.Float64() < density {
+				cell = 255
+			}
+			for _, image := range images(x, y) {
+				world[image.y][image.x] = cell
+				decided[image.y][image.x] = true
+			}
+		}
+	}
+	return world, nil
+}
+
+// cellImage is where a cell ends up under one of the transformations of a symmetry.
+type cellImage struct {
+	x, y int
+}
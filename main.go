@@ -2,10 +2,13 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -13,6 +16,7 @@ import (
 	"uk.ac.bris.cs/gameoflife/gol"
 	"uk.ac.bris.cs/gameoflife/sdl"
 	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/web"
 )
 
 // main is the function called when starting Game of Life with 'go run .'
@@ -42,33 +46,399 @@ func main() {
 		&params.Turns,
 		"turns",
 		10000000000,
-		"Specify the number of turns to process. Defaults to 10000000000.")
+		"Specify the number of turns to process, counting those before the turn a run is resumed from. Defaults to 10000000000.")
+
+	input := flag.String(
+		"input",
+		"",
+		"Specify any image, snapshot or pattern file to load, the size of the board is read from its header unless given with -w and -h.")
+
+	resume := flag.String(
+		"resume",
+		"",
+		"Specify a snapshot or saved image to continue a run from, starting at the turn it was saved at.")
+
+	resumeTurn := flag.Int(
+		"turn",
+		-1,
+		"Specify the turn a run continued with -resume starts at. Needed for images not named {w}x{h}x{turn} as saved.")
+
+	flag.BoolVar(
+		&params.Soup,
+		"soup",
+		false,
+		"Start from a random soup of the size given with -w and -h instead of an image.")
+
+	flag.Int64Var(
+		&params.Seed,
+		"seed",
+		0,
+		"Specify the seed of the random soup. Defaults to a new seed every run, which is logged and saved with the output.")
+
+	flag.Float64Var(
+		&params.Density,
+		"density",
+		0.5,
+		"Specify the fraction of the random soup that starts alive. Defaults to 0.5.")
+
+	flag.StringVar(
+		&params.Symmetry,
+		"symmetry",
+		"none",
+		"Specify the symmetry of the random soup, one of "+strings.Join(gol.Symmetries, ", ")+". Defaults to none.")
+
+	flag.StringVar(
+		&params.Pattern,
+		"pattern",
+		"",
+		"Specify an rle, cells, lif or life pattern file to load instead of the pgm image.")
+
+	at := flag.String(
+		"at",
+		"",
+		"Specify the x,y position of the top left corner of the pattern. Defaults to centring it.")
+
+	flag.StringVar(
+		&params.OutputFormat,
+		"format",
+		"pgm",
+		"Specify the format of output images, one of "+strings.Join(gol.OutputFormats, ", ")+". Defaults to pgm.")
+
+	flag.StringVar(
+		&params.OutDir,
+		"out",
+		gol.DefaultOutDir,
+		"Specify the directory to write output files to.")
+
+	flag.StringVar(
+		&params.OutName,
+		"outname",
+		gol.DefaultOutName,
+		"Specify the name of output files, where {w}, {h}, {turn} and {name} are replaced by the board size, turn and input name.")
+
+	flag.StringVar(
+		&params.PasteFile,
+		"paste",
+		"",
+		"Specify a pnm image or an rle, cells, lif or life pattern to paste into the world with the 'v' key.")
+
+	flag.BoolVar(
+		&params.FastForward,
+		"fastforward",
+		false,
+		"Skip straight to the final turn once the world has become periodic.")
+
+	flag.StringVar(
+		&params.StatsFile,
+		"stats",
+		"",
+		"Write the population statistics of every turn to a CSV file in headless mode.")
+
+	flag.StringVar(
+		&params.SummaryFile,
+		"summary",
+		"",
+		"Write a JSON summary of the run to this file when it ends in headless mode.")
+
+	flag.IntVar(
+		&params.GifEvery,
+		"gif",
+		0,
+		"Record every Nth generation into an animated GIF in the out directory. Defaults to no recording.")
+
+	flag.IntVar(
+		&params.GifFrames,
+		"gifframes",
+		500,
+		"Specify the most frames to record into the GIF, 0 for no limit.")
+
+	flag.IntVar(
+		&params.GifScale,
+		"gifscale",
+		1,
+		"Specify the size in pixels of each cell in the GIF.")
+
+	flag.IntVar(
+		&params.TurnRate,
+		"rate",
+		0,
+		"Run at most N turns per second, changed while running with + and -. Defaults to 0 for unlimited.")
+
+	flag.IntVar(
+		&params.SnapshotEvery,
+		"snapevery",
+		0,
+		"Save a snapshot every N turns. Defaults to none.")
+
+	flag.DurationVar(
+		&params.SnapshotInterval,
+		"snapinterval",
+		0,
+		"Save a snapshot at most this often, e.g. 10m. Defaults to none.")
+
+	snapTurns := flag.String(
+		"snapturns",
+		"",
+		"Save a snapshot at each of a comma separated list of turns.")
+
+	flag.IntVar(
+		&params.SnapshotKeep,
+		"snapkeep",
+		0,
+		"Keep only the last K automatic snapshots, 0 to keep them all.")
+
+	flag.IntVar(
+		&params.SnapshotKeepEvery,
+		"snapkeepevery",
+		0,
+		"Keep the first automatic snapshot of every M turns as well as the last ones.")
 
 	headless := flag.Bool(
 		"headless",
 		false,
 		"Disable the SDL window for running in a headless environment.")
 
+	terminal := flag.Bool(
+		"terminal",
+		false,
+		"Render the board live in the terminal instead of the SDL window.")
+
+	live := flag.Bool(
+		"live",
+		true,
+		"Show every generation as it is computed in the SDL window or terminal, rather than only when paused.")
+
+	frames := flag.String(
+		"frames",
+		"",
+		"Export frames of the run to this directory as a numbered image sequence, or to a Y4M video file (- for stdout).")
+
+	framesFormat := flag.String(
+		"framesformat",
+		"",
+		"Specify the format of exported frames: png, ppm or y4m. Defaults to y4m for .y4m files and stdout, png otherwise.")
+
+	framesEvery := flag.Int(
+		"framesevery",
+		1,
+		"Export a frame every N turns.")
+
+	framesScale := flag.Int(
+		"framesscale",
+		1,
+		"Specify the size in pixels of each cell in exported frames.")
+
+	framesFPS := flag.Int(
+		"framesfps",
+		25,
+		"Specify the frame rate of exported Y4M video.")
+
+	webAddr := flag.String(
+		"web",
+		"",
+		"Serve a live view of the board to browsers on this address, such as localhost:8080.")
+
+	record := flag.String(
+		"record",
+		"",
+		"Record every event of the run to a JSON-lines event log.")
+
+	replay := flag.String(
+		"replay",
+		"",
+		"Replay a recorded event log instead of running Game of Life. In the SDL window it can be played, paused, stepped and scrubbed through.")
+
 	flag.Parse()
+	params.Statistics = params.StatsFile != ""
+	params.Live = (*live && (*terminal || !*headless || *webAddr != "")) || *frames != ""
+	if *at != "" {
+		_, err := fmt.Sscanf(*at, "%d,%d", &params.PatternAt.X, &params.PatternAt.Y)
+		util.Check(err)
+		params.PlacePattern = true
+	}
+	if *input != "" {
+		readInput(&params, *input)
+	}
+	if *resume != "" {
+		readInput(&params, *resume)
+		if *resumeTurn >= 0 {
+			params.StartTurn = *resumeTurn
+		} else {
+			turn, err := gol.ReadTurn(*resume)
+			if err != nil {
+				log.Fatalf("[Main] %v Failed to resume from %v: %v, give the turn with -turn", util.Red("ERROR"), *resume, err)
+			}
+			params.StartTurn = turn
+		}
+	} else if *resumeTurn >= 0 {
+		log.Fatalf("[Main] %v -turn is only used with -resume", util.Red("ERROR"))
+	}
+	if *snapTurns != "" {
+		for _, field := range strings.Split(*snapTurns, ",") {
+			turn, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				log.Fatalf("[Main] %v Invalid snapshot turn %q", util.Red("ERROR"), field)
+			}
+			params.SnapshotTurns = append(params.SnapshotTurns, turn)
+		}
+	}
+	if params.Soup {
+		seeded := false
+		flag.Visit(func(f *flag.Flag) {
+			seeded = seeded || f.Name == "seed"
+		})
+		if !seeded {
+			params.Seed = time.Now().UnixNano()
+		}
+		known := false
+		for _, symmetry := range gol.Symmetries {
+			known = known || params.Symmetry == symmetry
+		}
+		if !known {
+			log.Fatalf("[Main] %v Unknown symmetry %v", util.Red("ERROR"), params.Symmetry)
+		}
+	}
+	known := false
+	for _, format := range gol.OutputFormats {
+		known = known || params.OutputFormat == format
+	}
+	if !known {
+		log.Fatalf("[Main] %v Unknown output format %v", util.Red("ERROR"), params.OutputFormat)
+	}
+	if params.TurnRate < 0 {
+		log.Fatalf("[Main] %v Invalid turn rate %v", util.Red("ERROR"), params.TurnRate)
+	}
+
+	if *frames != "" && *framesFormat == "" {
+		*framesFormat = "png"
+		if *frames == "-" || strings.HasSuffix(*frames, ".y4m") {
+			*framesFormat = "y4m"
+		}
+	}
+	// exportFrames passes events on to out through a frame exporter when frames are to be exported.
+	exportFrames := func(p gol.Params, in <-chan gol.Event, out chan gol.Event) {
+		exporter, err := gol.NewFrameExporter(*frames, *framesFormat, *framesEvery, *framesScale, *framesFPS, p)
+		if err != nil {
+			log.Fatalf("[Main] %v Failed to export frames to %v: %v", util.Red("ERROR"), *frames, err)
+		}
+		log.Printf("[Main] %-10v %v", "Frames", *frames)
+		go exporter.Forward(in, out)
+	}
+
+	if *replay != "" && !*headless && !*terminal && *frames == "" {
+		eventLog, err := gol.LoadEventLog(*replay)
+		if err != nil {
+			log.Fatalf("[Main] %v Failed to load %v: %v", util.Red("ERROR"), *replay, err)
+		}
+		defer eventLog.Close()
+		log.Printf("[Main] %-10v %v", "Replay", *replay)
+		sdl.RunPlayback(eventLog)
+		return
+	}
+	if *replay != "" {
+		recorded, events, err := gol.Replay(*replay, true)
+		util.Check(err)
+		log.Printf("[Main] %-10v %v", "Replay", *replay)
+		if *frames != "" {
+			exported := make(chan gol.Event, 1000)
+			exportFrames(recorded, events, exported)
+			events = exported
+		}
+		runReplay(recorded, events, *headless, *terminal)
+		return
+	}
 
 	log.Printf("[Main] %-10v %v", "Threads", params.Threads)
 	log.Printf("[Main] %-10v %v", "Width", params.ImageWidth)
 	log.Printf("[Main] %-10v %v", "Height", params.ImageHeight)
 	log.Printf("[Main] %-10v %v", "Turns", params.Turns)
+	if params.Soup {
+		log.Printf("[Main] %-10v %v", "Seed", params.Seed)
+	}
+	if params.StartTurn != 0 {
+		log.Printf("[Main] %-10v %v", "Resume", params.StartTurn)
+	}
 
 	keyPresses := make(chan rune, 10)
 	events := make(chan gol.Event, 1000)
 
 	go sigint()
 
-	go gol.Run(params, events, keyPresses)
-	if !*headless {
+	// The events of the run pass through the recorder and the web viewer, if there are any, on their way to the front end.
+	run := events
+	if *webAddr != "" {
+		viewer := web.NewViewer(params, keyPresses)
+		go func() {
+			log.Printf("[Main] %-10v http://%v", "Web", *webAddr)
+			if err := viewer.Serve(*webAddr); err != nil {
+				log.Printf("[Web] %v %v", util.Red("ERROR"), err)
+			}
+		}()
+		viewed := make(chan gol.Event, 1000)
+		go viewer.Forward(viewed, run)
+		run = viewed
+	}
+	if *frames != "" {
+		exported := make(chan gol.Event, 1000)
+		exportFrames(params, exported, run)
+		run = exported
+	}
+	if *record != "" {
+		recorder, err := gol.NewRecorder(*record, params)
+		util.Check(err)
+		recorded := make(chan gol.Event, 1000)
+		go recorder.Forward(recorded, run)
+		run = recorded
+	}
+	go gol.Run(params, run, keyPresses)
+	runFrontEnd(params, events, keyPresses, *headless, *terminal)
+}
+
+// readInput sets up params to load the image or pattern at path, taking the size of the board from its header.
+// The size of an image always comes from the file, a pattern may be placed on a board given with -w and -h instead.
+func readInput(params *gol.Params, path string) {
+	width, height, err := gol.ReadSize(path)
+	if err != nil {
+		log.Fatalf("[Main] %v Failed to read %v: %v", util.Red("ERROR"), path, err)
+	}
+
+	sized := false
+	flag.Visit(func(f *flag.Flag) {
+		sized = sized || f.Name == "w" || f.Name == "h"
+	})
+	if gol.IsImage(path) {
+		params.Input = path
+	} else {
+		params.Pattern = path
+		if sized {
+			return
+		}
+	}
+	params.ImageWidth = width
+	params.ImageHeight = height
+}
+
+// runFrontEnd shows the run in the terminal, in the SDL window or, when headless, only in the log.
+func runFrontEnd(params gol.Params, events <-chan gol.Event, keyPresses chan<- rune, headless, terminal bool) {
+	if terminal {
+		sdl.RunTerminal(params, events, keyPresses)
+	} else if !headless {
 		sdl.Run(params, events, keyPresses)
 	} else {
-		sdl.RunHeadless(events)
+		sdl.RunHeadless(params, events)
 	}
 }
 
+// runReplay shows a replayed run, where there is no engine to send key presses to.
+func runReplay(params gol.Params, events <-chan gol.Event, headless, terminal bool) {
+	keyPresses := make(chan rune, 10)
+	go func() {
+		for range keyPresses {
+		}
+	}()
+	runFrontEnd(params, events, keyPresses, headless, terminal)
+}
+
 func sigint() {
 	sigint := make(chan os.Signal, 1)
 	signal.Notify(sigint, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,248 @@
+package sdl
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// fillDensity is the share of the selection left alive by filling it at random.
+const fillDensity = 0.5
+
+// selectionColour is the colour of the outline of the selection.
+var selectionColour = rgb(60, 170, 255)
+
+// draw is the draw mode of the live window, in which the board is edited by hand while the run is paused.
+// The left button toggles the cell clicked and sets the cells dragged over to match,
+// and dragging the right button selects a rectangle of cells for the keys to act on.
+// Edits are only made to the sketch in the window until the run is resumed, when they are sent to the engine all at once.
+type draw struct {
+	w      *Window
+	p      gol.Params
+	active bool
+	turn   int
+	sketch *gol.Sketch
+
+	// painting is set while the left button is held, setting the cells dragged over to paint.
+	// last is the last cell painted, from which the cells skipped by a fast drag are filled in.
+	painting bool
+	paint    byte
+	last     util.Cell
+	// selecting is set while the right button is held, selecting the cells from anchor to the mouse.
+	selecting bool
+	anchor    util.Cell
+
+	// cursor is the cell last under the mouse, where the clipboard is pasted.
+	// The clipboard is kept from one pause to the next.
+	cursor    util.Cell
+	clipboard [][]byte
+	random    *rand.Rand
+}
+
+func newDraw(w *Window, p gol.Params) *draw {
+	return &draw{
+		w:      w,
+		p:      p,
+		cursor: util.Cell{X: p.ImageWidth / 2, Y: p.ImageHeight / 2},
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// begin enters draw mode on the board shown at turn.
+func (d *draw) begin(turn int) {
+	d.active, d.turn = true, turn
+	world := make([][]byte, d.w.Height)
+	for y := range world {
+		world[y] = make([]byte, d.w.Width)
+		for x := range world[y] {
+			if d.w.IsAlive(x, y) {
+				world[y][x] = 0xFF
+			}
+		}
+	}
+	d.sketch = gol.NewSketch(world, d.w.FlipPixel)
+	d.painting, d.selecting = false, false
+	d.w.SetSelection(image.Rectangle{})
+	log.Printf("[SDL] Draw mode at turn %v", turn)
+}
+
+// end leaves draw mode, returning the cells edited as those to set and clear on the engine's board.
+func (d *draw) end() (set, clear []util.Cell) {
+	d.active = false
+	d.w.SetSelection(image.Rectangle{})
+	return d.sketch.Edits()
+}
+
+// handle edits the board for a mouse event, reporting whether it did.
+// Mouse events it does not handle are left to the viewport, so the middle button still pans.
+func (d *draw) handle(event sdl.Event) bool {
+	defer d.w.SetSelection(d.sketch.Selection)
+	switch e := event.(type) {
+	case *sdl.MouseButtonEvent:
+		if e.Button != sdl.BUTTON_LEFT && e.Button != sdl.BUTTON_RIGHT {
+			return false
+		}
+		cell, ok := d.w.Cell(e.X, e.Y)
+		if e.State == sdl.RELEASED || !ok {
+			d.painting, d.selecting = false, false
+			return true
+		}
+		d.cursor = cell
+		if e.Button == sdl.BUTTON_LEFT {
+			d.painting, d.last = true, cell
+			d.paint = 0xFF
+			if d.sketch.Alive(cell.X, cell.Y) {
+				d.paint = 0
+			}
+			d.sketch.Set(cell.X, cell.Y, d.paint)
+		} else {
+			d.selecting, d.anchor = true, cell
+			d.selectTo(cell)
+		}
+		return true
+	case *sdl.MouseMotionEvent:
+		cell, ok := d.w.Cell(e.X, e.Y)
+		if ok {
+			d.cursor = cell
+		}
+		switch {
+		case d.painting && e.State&sdl.ButtonLMask() != 0:
+			if ok {
+				d.sketch.Line(d.last, cell, d.paint)
+				d.last = cell
+			}
+			return true
+		case d.selecting && e.State&sdl.ButtonRMask() != 0:
+			d.selectTo(cell)
+			return true
+		}
+	}
+	return false
+}
+
+// key acts on the selection for a key pressed in draw mode, reporting whether the key has anything to do.
+func (d *draw) key(key sdl.Keycode) bool {
+	defer d.w.SetSelection(d.sketch.Selection)
+	switch key {
+	case sdl.K_DELETE, sdl.K_BACKSPACE, sdl.K_x:
+		d.sketch.Fill(func() byte { return 0 })
+	case sdl.K_n:
+		d.sketch.Fill(func() byte {
+			if d.random.Float64() < fillDensity {
+				return 0xFF
+			}
+			return 0
+		})
+	case sdl.K_c:
+		if !d.sketch.Selection.Empty() {
+			d.clipboard = d.sketch.Selected()
+		}
+	case sdl.K_v:
+		d.paste()
+	case sdl.K_r:
+		d.sketch.Rotate()
+	case sdl.K_w:
+		d.save()
+	default:
+		return false
+	}
+	return true
+}
+
+// selectTo selects the cells from the anchor to cell, which is kept on the board.
+func (d *draw) selectTo(cell util.Cell) {
+	x := clamp(cell.X, 0, int(d.w.Width)-1)
+	y := clamp(cell.Y, 0, int(d.w.Height)-1)
+	r := image.Rect(d.anchor.X, d.anchor.Y, x, y)
+	r.Max = r.Max.Add(image.Pt(1, 1))
+	d.sketch.Select(r)
+}
+
+// paste puts the clipboard on the board at the cursor. Before anything is copied, the clipboard holds the -paste file.
+func (d *draw) paste() {
+	if d.clipboard == nil && d.p.PasteFile != "" {
+		grid, err := gol.LoadPattern(d.p.PasteFile)
+		if err != nil {
+			log.Printf("[SDL] %v Failed to load %v: %v", util.Yellow("WARN"), d.p.PasteFile, err)
+			return
+		}
+		d.clipboard = grid
+	}
+	d.sketch.Put(d.clipboard, d.cursor)
+}
+
+// save writes the selected cells to the output directory as an RLE pattern.
+func (d *draw) save() {
+	if d.sketch.Selection.Empty() {
+		return
+	}
+	path, err := d.p.SaveSelection(d.sketch.Selected(), d.turn)
+	if err != nil {
+		log.Printf("[SDL] %v Failed to save the selection: %v", util.Red("ERROR"), err)
+		return
+	}
+	log.Printf("[SDL] Selection saved to %v", path)
+}
+
+// lines returns the lines the HUD shows in draw mode.
+func (d *draw) lines() []string {
+	set, clear := d.sketch.Edits()
+	lines := []string{fmt.Sprintf("Draw      %v edits", len(set)+len(clear))}
+	if selection := d.sketch.Selection; !selection.Empty() {
+		lines = append(lines, fmt.Sprintf("Selection %vx%v", selection.Dx(), selection.Dy()))
+	}
+	if len(d.clipboard) > 0 {
+		lines = append(lines, fmt.Sprintf("Clipboard %vx%v", len(d.clipboard[0]), len(d.clipboard)))
+	}
+	return lines
+}
+
+// IsAlive reports whether the window shows the cell at (x, y) alive.
+func (w *Window) IsAlive(x, y int) bool {
+	return w.pixels[4*(y*int(w.Width)+x)] == 0xFF
+}
+
+// SetSelection sets the rectangle of cells outlined over the board. An empty rectangle outlines nothing.
+func (w *Window) SetSelection(r image.Rectangle) {
+	w.selection = r
+}
+
+// drawSelection outlines the selection in the view, at least a pixel of the window wide and high.
+func (w *Window) drawSelection() {
+	if w.selection.Empty() {
+		return
+	}
+	thickness := w.pixelScale()
+	toView := func(cell int, start float64) int {
+		return int(math.Round((float64(cell) - start) * w.zoom))
+	}
+	left, right := toView(w.selection.Min.X, w.left), toView(w.selection.Max.X, w.left)
+	top, bottom := toView(w.selection.Min.Y, w.top), toView(w.selection.Max.Y, w.top)
+	if right-left < thickness {
+		right = left + thickness
+	}
+	if bottom-top < thickness {
+		bottom = top + thickness
+	}
+	w.fillView(left, top, right, top+thickness)
+	w.fillView(left, bottom-thickness, right, bottom)
+	w.fillView(left, top, left+thickness, bottom)
+	w.fillView(right-thickness, top, right, bottom)
+}
+
+// fillView colours the pixels of the view from (x0, y0) up to (x1, y1), clipped to the view.
+func (w *Window) fillView(x0, y0, x1, y1 int) {
+	viewWidth, viewHeight := int(w.viewWidth), int(w.viewHeight)
+	for y := clamp(y0, 0, viewHeight); y < clamp(y1, 0, viewHeight); y++ {
+		for x := clamp(x0, 0, viewWidth); x < clamp(x1, 0, viewWidth); x++ {
+			copy(w.view[4*(y*viewWidth+x):], selectionColour[:])
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package sdl
+
+import "math"
+
+// RenderMode is how the cells of the board are coloured in the window.
+type RenderMode int
+
+const (
+	// Plain draws alive cells white and dead cells black.
+	Plain RenderMode = iota
+	// Age colours alive cells by the number of generations they have been alive,
+	// and dead cells by how long ago they died, so that trails fade out behind moving patterns.
+	Age
+	// Activity colours every cell by how long ago it last changed, leaving stable debris dim.
+	Activity
+	renderModes
+)
+
+func (mode RenderMode) String() string {
+	switch mode {
+	case Plain:
+		return "plain"
+	case Age:
+		return "age"
+	case Activity:
+		return "activity"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// trailTurns is how many turns the trail left by a dead cell takes to fade out.
+	trailTurns = 64
+	// activityTurns is how many turns a cell takes to cool down after it last changed.
+	activityTurns = 128
+	// never is the turn of the last change of a cell that has not changed since changes were first tracked.
+	never = math.MinInt64 / 2
+)
+
+// colour is a colour in the byte order of the ARGB8888 pixels of the window.
+type colour [4]byte
+
+var (
+	agePalette      = palette([]colour{rgb(255, 255, 170), rgb(255, 160, 40), rgb(225, 50, 50), rgb(170, 50, 210), rgb(60, 90, 255)})
+	trailPalette    = palette([]colour{rgb(40, 120, 220), rgb(0, 0, 0)})
+	activityPalette = palette([]colour{rgb(255, 255, 255), rgb(255, 220, 60), rgb(210, 50, 20), rgb(50, 0, 0)})
+	stable          = rgb(70, 70, 70)
+	dead            = rgb(0, 0, 0)
+)
+
+func rgb(r, g, b byte) colour {
+	return colour{b, g, r, 0xFF}
+}
+
+// palette spreads 256 colours evenly between the given stops.
+func palette(stops []colour) [256]colour {
+	var p [256]colour
+	for i := range p {
+		t := float64(i) / 255 * float64(len(stops)-1)
+		stop := int(t)
+		if stop == len(stops)-1 {
+			p[i] = stops[stop]
+			continue
+		}
+		f := t - float64(stop)
+		for c := 0; c < 3; c++ {
+			p[i][c] = byte(float64(stops[stop][c])*(1-f) + float64(stops[stop+1][c])*f)
+		}
+		p[i][3] = 0xFF
+	}
+	return p
+}
+
+// SetTurn sets the turn that the flips which follow belong to, and that ages are measured up to.
+func (w *Window) SetTurn(turn int) {
+	w.turn = turn
+}
+
+// CycleMode switches to the next render mode.
+// Changes are only tracked from the first time a mode other than Plain is used,
+// so cells that have not changed since then are drawn as old.
+func (w *Window) CycleMode() RenderMode {
+	w.mode = (w.mode + 1) % renderModes
+	if w.mode != Plain && w.changed == nil {
+		w.changed = make([]int64, int(w.Width)*int(w.Height))
+		for i := range w.changed {
+			w.changed[i] = never
+		}
+	}
+	w.window.SetTitle("GOL GUI - " + w.mode.String())
+	return w.mode
+}
+
+// cellColour returns the colour of the i-th cell of the board in the current render mode.
+func (w *Window) cellColour(i int) colour {
+	alive := w.pixels[4*i] == 0xFF
+	if w.mode == Plain {
+		if alive {
+			return rgb(0xFF, 0xFF, 0xFF)
+		}
+		return dead
+	}
+
+	// Ages are only told apart for the first few hundred turns, so they are capped before being made an int.
+	age := int64(w.turn) - w.changed[i]
+	since := 0
+	if age > math.MaxInt32 {
+		since = math.MaxInt32
+	} else if age > 0 {
+		since = int(age)
+	}
+	switch {
+	case w.mode == Age && alive:
+		return agePalette[clamp(int(math.Log2(float64(since)+1)*32), 0, 255)]
+	case w.mode == Age && since < trailTurns:
+		return trailPalette[since*256/trailTurns]
+	case w.mode == Activity && since < activityTurns:
+		return activityPalette[since*256/activityTurns]
+	case alive:
+		return stable
+	default:
+		return dead
+	}
+}
@@ -0,0 +1,160 @@
+package sdl
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+const (
+	// hudScale is how many pixels of the window each pixel of the font covers, before scaling for high-DPI screens.
+	hudScale = 2
+	// hudPadding is the space in font pixels around the text of the HUD and between its lines.
+	hudPadding = 3
+	// hudInterval is how often the turns per second shown in the HUD are measured.
+	hudInterval = time.Second / 2
+	// hudSmoothing is the weight of the last measurement in the turns per second shown.
+	hudSmoothing = 0.4
+)
+
+// hud is what the overlay of the window shows about the run.
+type hud struct {
+	turn     int
+	state    string
+	snapshot string
+	// turnRate is the limit on turns per second, or 0 when there is none.
+	turnRate int
+
+	turnsPerSec float64
+	// measured is when, and measuredTurn the turn at which, turns per second were last measured.
+	measured     time.Time
+	measuredTurn int
+}
+
+func newHUD(p gol.Params) *hud {
+	return &hud{turn: p.StartTurn, measured: time.Now(), measuredTurn: p.StartTurn}
+}
+
+// update keeps the HUD up to date with an event, reporting whether anything it shows changed.
+func (h *hud) update(event gol.Event) bool {
+	switch e := event.(type) {
+	case gol.StateChange:
+		h.turn = e.CompletedTurns
+		h.state = e.NewState.String()
+	case gol.TurnRateChanged:
+		h.turnRate = e.TurnRate
+	case gol.ImageOutputComplete:
+		// Pgm images are the default and are named without their extension.
+		h.snapshot = e.Filename
+		if filepath.Ext(e.Filename) == "" {
+			h.snapshot += ".pgm"
+		}
+	case gol.CellFlipped, gol.CellsFlipped:
+		return false
+	default:
+		if event.GetCompletedTurns() == h.turn {
+			return false
+		}
+		h.turn = event.GetCompletedTurns()
+	}
+	return true
+}
+
+// measure updates the turns per second every hudInterval, reporting whether it did.
+// Each measurement is blended into the last, so that the rate shown does not jump with every batch of turns.
+func (h *hud) measure() bool {
+	elapsed := time.Since(h.measured)
+	if elapsed < hudInterval {
+		return false
+	}
+	// Seeking back while paused does not count as turns run backwards.
+	turns := h.turn - h.measuredTurn
+	if turns < 0 {
+		turns = 0
+	}
+	h.turnsPerSec = hudSmoothing*float64(turns)/elapsed.Seconds() + (1-hudSmoothing)*h.turnsPerSec
+	h.measured, h.measuredTurn = time.Now(), h.turn
+	return true
+}
+
+// lines returns the text of the HUD for a board with the given number of alive cells.
+func (h *hud) lines(alive int) []string {
+	limit := "unlimited"
+	if h.turnRate > 0 {
+		limit = fmt.Sprint(h.turnRate)
+	}
+	lines := []string{
+		fmt.Sprintf("Turn      %v", h.turn),
+		fmt.Sprintf("Alive     %v", alive),
+		fmt.Sprintf("Turns/sec %.0f", h.turnsPerSec),
+		fmt.Sprintf("Limit     %v", limit),
+		fmt.Sprintf("State     %v", h.state),
+	}
+	if h.snapshot != "" {
+		lines = append(lines, fmt.Sprintf("Snapshot  %v", h.snapshot))
+	}
+	return lines
+}
+
+// SetHUD sets the lines of text drawn over the top left corner of the board. With no lines, nothing is drawn.
+func (w *Window) SetHUD(lines []string) {
+	w.overlay = lines
+}
+
+// drawHUD draws the lines of the HUD into the view, over a darkened box.
+func (w *Window) drawHUD() {
+	if len(w.overlay) == 0 {
+		return
+	}
+	scale := hudScale * w.pixelScale()
+	columns := 0
+	for _, line := range w.overlay {
+		if len(line) > columns {
+			columns = len(line)
+		}
+	}
+	lineHeight := glyphHeight + hudPadding
+	boxWidth := (columns*(glyphWidth+1) - 1 + 2*hudPadding) * scale
+	boxHeight := (len(w.overlay)*lineHeight + hudPadding) * scale
+	viewWidth, viewHeight := int(w.viewWidth), int(w.viewHeight)
+
+	for y := 0; y < boxHeight && y < viewHeight; y++ {
+		for x := 0; x < boxWidth && x < viewWidth; x++ {
+			pixel := w.view[4*(y*viewWidth+x):]
+			pixel[0], pixel[1], pixel[2] = pixel[0]/4, pixel[1]/4, pixel[2]/4
+		}
+	}
+	for row, line := range w.overlay {
+		top := (hudPadding + row*lineHeight) * scale
+		for column, r := range []rune(line) {
+			left := (hudPadding + column*(glyphWidth+1)) * scale
+			for gy, bits := range glyph(r) {
+				for gx := 0; gx < glyphWidth; gx++ {
+					if bits&(1<<(glyphWidth-1-gx)) != 0 {
+						w.fillHUD(left+gx*scale, top+gy*scale, scale)
+					}
+				}
+			}
+		}
+	}
+}
+
+// pixelScale is how many pixels of the view make up a pixel of the window, which is more than one on high-DPI screens.
+func (w *Window) pixelScale() int {
+	if _, height := w.window.GetSize(); height > 0 && w.viewHeight >= 2*height {
+		return int(w.viewHeight / height)
+	}
+	return 1
+}
+
+// fillHUD draws a pixel of the font as a square of size x size pixels of the view, clipped to the view.
+func (w *Window) fillHUD(left, top, size int) {
+	viewWidth, viewHeight := int(w.viewWidth), int(w.viewHeight)
+	for y := top; y < top+size && y < viewHeight; y++ {
+		for x := left; x < left+size && x < viewWidth; x++ {
+			setGrey(w.view[4*(y*viewWidth+x):], 0xE0)
+		}
+	}
+}
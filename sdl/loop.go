@@ -2,6 +2,7 @@ package sdl
 
 import (
 	"log"
+	"math"
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
@@ -11,23 +12,84 @@ import (
 
 const FPS = 60
 
+const (
+	// panStep is the fraction of the window panned by an arrow key.
+	panStep = 0.125
+	// wheelZoom is the zoom factor of one step of the mouse wheel.
+	wheelZoom = 1.25
+	// dragThreshold is how many pixels the mouse must move with a button held to pan rather than click.
+	dragThreshold = 4
+)
+
+// Run shows the board in a window, passing on the keys of the engine: p pauses, s saves, q quits, k shuts the engine down,
+// + and - change the turn rate, and while paused digits then g jump to a turn and , and . step a turn back and forward.
+// The mouse wheel, Page Up and Page Down zoom, and the arrow keys or a drag pan (see viewport).
+// Clicking sets a cell and right clicking clears it, v pastes the -paste file where the mouse was last clicked,
+// d enters draw mode while paused and h shows the HUD.
 func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
 	w := NewWindow(int32(p.ImageWidth), int32(p.ImageHeight))
 	defer w.Destroy()
 	dirty := false
 	refreshTicker := time.NewTicker(time.Second / time.Duration(FPS))
-	avgTurns := util.NewAvgTurns()
+	avgTurns := util.NewAvgTurnsFrom(p.StartTurn)
+	status, showHUD := newHUD(p), false
+	// Patterns are pasted where the mouse was last clicked.
+	cursor := util.Cell{X: p.ImageWidth / 2, Y: p.ImageHeight / 2}
+	view := &viewport{w: w}
+	edit := func(err error) {
+		if err != nil {
+			log.Printf("[SDL] %v Edit failed: %v", util.Yellow("WARN"), err)
+		}
+	}
+	// Cells drawn in draw mode stay in the window until the engine sends the board it made from them.
+	editor, paused := newDraw(w, p), false
+	var drawn []util.Cell
+	drawnAt := 0
+	drawKey := func(key sdl.Keycode) {
+		switch key {
+		case sdl.K_p:
+			set, clear := editor.end()
+			if len(set)+len(clear) > 0 {
+				edit(gol.EditCells(set, clear))
+				drawn = append(append(drawn, set...), clear...)
+				drawnAt = editor.turn
+			}
+			keyPresses <- 'p'
+		case sdl.K_ESCAPE:
+			set, clear := editor.end()
+			for _, cell := range append(set, clear...) {
+				w.FlipPixel(cell.X, cell.Y)
+			}
+			log.Printf("[SDL] Draw mode cancelled")
+		case sdl.K_q:
+			keyPresses <- 'q'
+		case sdl.K_k:
+			keyPresses <- 'k'
+		case sdl.K_h:
+			showHUD = !showHUD
+		default:
+			editor.key(key)
+		}
+	}
 
 sdl:
 	for {
 		select {
 		case <-refreshTicker.C:
-			event := w.PollEvent()
-			if event != nil {
+			for event := w.PollEvent(); event != nil; event = w.PollEvent() {
+				if editor.active && editor.handle(event) || view.handle(event) {
+					dirty = true
+					continue
+				}
 				switch e := event.(type) {
 				case *sdl.QuitEvent:
 					keyPresses <- 'q'
 				case *sdl.KeyboardEvent:
+					dirty = true
+					if editor.active {
+						drawKey(e.Keysym.Sym)
+						break
+					}
 					switch e.Keysym.Sym {
 					case sdl.K_ESCAPE:
 						keyPresses <- 'q'
@@ -39,10 +101,54 @@ sdl:
 						keyPresses <- 'q'
 					case sdl.K_k:
 						keyPresses <- 'k'
+					case sdl.K_0, sdl.K_1, sdl.K_2, sdl.K_3, sdl.K_4, sdl.K_5, sdl.K_6, sdl.K_7, sdl.K_8, sdl.K_9:
+						keyPresses <- rune('0' + e.Keysym.Sym - sdl.K_0)
+					case sdl.K_g:
+						keyPresses <- 'g'
+					case sdl.K_COMMA:
+						keyPresses <- ','
+					case sdl.K_PERIOD:
+						keyPresses <- '.'
+					case sdl.K_PLUS, sdl.K_EQUALS, sdl.K_KP_PLUS:
+						keyPresses <- '+'
+					case sdl.K_MINUS, sdl.K_KP_MINUS:
+						keyPresses <- '-'
+					case sdl.K_v:
+						if p.PasteFile != "" {
+							edit(gol.PastePattern(p.PasteFile, cursor))
+						}
+					case sdl.K_d:
+						if paused {
+							editor.begin(status.turn)
+						} else {
+							log.Printf("[SDL] Pause with p to draw")
+						}
+					case sdl.K_h:
+						showHUD = !showHUD
+					}
+				case *sdl.MouseButtonEvent:
+					cell, ok := w.Cell(e.X, e.Y)
+					if !ok || view.dragging {
+						break
+					}
+					cursor = cell
+					switch {
+					case e.Button == sdl.BUTTON_LEFT && e.State == sdl.RELEASED:
+						edit(gol.EditCells([]util.Cell{cursor}, nil))
+					case e.Button == sdl.BUTTON_RIGHT && e.State == sdl.PRESSED:
+						edit(gol.EditCells(nil, []util.Cell{cursor}))
 					}
 				}
 			}
+			dirty = status.measure() && showHUD || dirty
 			if dirty {
+				if showHUD && editor.active {
+					w.SetHUD(append(status.lines(w.Alive()), editor.lines()...))
+				} else if showHUD {
+					w.SetHUD(status.lines(w.Alive()))
+				} else {
+					w.SetHUD(nil)
+				}
 				w.RenderFrame()
 				dirty = false
 			}
@@ -51,14 +157,24 @@ sdl:
 			if !ok {
 				break sdl
 			}
+			dirty = status.update(event) && showHUD || dirty
+			if len(drawn) > 0 && gol.CatchesUp(event, drawnAt) {
+				for _, cell := range drawn {
+					w.FlipPixel(cell.X, cell.Y)
+				}
+				drawn = nil
+			}
 			switch e := event.(type) {
 			case gol.CellFlipped:
+				w.SetTurn(e.CompletedTurns)
 				w.FlipPixel(e.Cell.X, e.Cell.Y)
 			case gol.CellsFlipped:
+				w.SetTurn(e.CompletedTurns)
 				for _, cell := range e.Cells {
 					w.FlipPixel(cell.X, cell.Y)
 				}
 			case gol.TurnComplete:
+				w.SetTurn(e.CompletedTurns)
 				dirty = true
 			case gol.AliveCellsCount:
 				log.Printf(
@@ -69,10 +185,15 @@ sdl:
 				)
 			case gol.FinalTurnComplete:
 				log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
+			case gol.StabilityDetected:
+				log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
 			case gol.ImageOutputComplete:
 				log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
+			case gol.TurnRateChanged, gol.EngineDetails:
+				log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
 			case gol.StateChange:
 				log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
+				paused = e.NewState == gol.Paused
 				if e.NewState == gol.Quitting {
 					break sdl
 				}
@@ -81,21 +202,112 @@ sdl:
 	}
 }
 
-func RunHeadless(events <-chan gol.Event) {
-	avgTurns := util.NewAvgTurns()
+// viewport handles the input that zooms and pans the window, which the live window and playback share.
+type viewport struct {
+	w *Window
+	// drag is how far the mouse has moved since a button was pressed, and dragging whether that was far enough to pan.
+	drag     util.Cell
+	dragging bool
+}
+
+// handle zooms or pans the window for an event, reporting whether the window needs to be drawn again as a result.
+// Mouse button events are never handled, so that clicks can be handled once it is known they did not start a drag.
+func (v *viewport) handle(event sdl.Event) bool {
+	w := v.w
+	switch e := event.(type) {
+	case *sdl.WindowEvent:
+		return true
+	case *sdl.KeyboardEvent:
+		switch e.Keysym.Sym {
+		case sdl.K_PAGEUP:
+			w.Zoom(2)
+		case sdl.K_PAGEDOWN:
+			w.Zoom(0.5)
+		case sdl.K_f:
+			w.Fit()
+		case sdl.K_m:
+			log.Printf("[SDL] Render mode %v", w.CycleMode())
+		case sdl.K_LEFT:
+			w.PanView(panStep, 0)
+		case sdl.K_RIGHT:
+			w.PanView(-panStep, 0)
+		case sdl.K_UP:
+			w.PanView(0, panStep)
+		case sdl.K_DOWN:
+			w.PanView(0, -panStep)
+		default:
+			return false
+		}
+		return true
+	case *sdl.MouseWheelEvent:
+		scroll := e.Y
+		if e.Direction == sdl.MOUSEWHEEL_FLIPPED {
+			scroll = -scroll
+		}
+		x, y, _ := sdl.GetMouseState()
+		w.ZoomAt(math.Pow(wheelZoom, float64(scroll)), x, y)
+		return true
+	case *sdl.MouseMotionEvent:
+		// Dragging with the left or middle button pans, once the mouse has moved far enough not to be a click.
+		if e.State&(sdl.ButtonLMask()|sdl.ButtonMMask()) == 0 {
+			return false
+		}
+		v.drag.X += int(e.XRel)
+		v.drag.Y += int(e.YRel)
+		if !v.dragging && v.drag.X*v.drag.X+v.drag.Y*v.drag.Y > dragThreshold*dragThreshold {
+			v.dragging = true
+			w.Pan(int32(v.drag.X), int32(v.drag.Y))
+		} else if v.dragging {
+			w.Pan(e.XRel, e.YRel)
+		}
+		return v.dragging
+	case *sdl.MouseButtonEvent:
+		if e.State == sdl.PRESSED {
+			v.drag, v.dragging = util.Cell{}, false
+		}
+	}
+	return false
+}
+
+func RunHeadless(p gol.Params, events <-chan gol.Event) {
+	avgTurns := util.NewAvgTurnsFrom(p.StartTurn)
+	var stats *statisticsWriter
+	if p.StatsFile != "" {
+		stats = newStatisticsWriter(p.StatsFile)
+		defer stats.close()
+	}
+	var results *summaryWriter
+	if p.SummaryFile != "" {
+		results = newSummaryWriter(p.SummaryFile, p, avgTurns)
+	}
 	for event := range events {
+		if results != nil {
+			results.update(event)
+		}
 		switch e := event.(type) {
+		case gol.TurnStatistics:
+			if stats != nil {
+				stats.write(e)
+			}
 		case gol.AliveCellsCount:
+			turnsPerSec := avgTurns.TurnsPerSec(event.GetCompletedTurns())
+			if results != nil {
+				results.rate(turnsPerSec)
+			}
 			log.Printf(
 				"[Event] Completed Turns %-8v %-20v Avg%+5v turns/sec\n",
 				event.GetCompletedTurns(),
 				event,
-				avgTurns.TurnsPerSec(event.GetCompletedTurns()),
+				turnsPerSec,
 			)
 		case gol.FinalTurnComplete:
 			log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), "Final Turn Complete")
+		case gol.StabilityDetected:
+			log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
 		case gol.ImageOutputComplete:
 			log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
+		case gol.TurnRateChanged, gol.EngineDetails:
+			log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
 		case gol.StateChange:
 			log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
 			if e.NewState == gol.Quitting {
@@ -103,4 +315,8 @@ func RunHeadless(events <-chan gol.Event) {
 			}
 		}
 	}
+	if results != nil {
+		results.write()
+		log.Printf("[Main] Summary written to %v", p.SummaryFile)
+	}
 }
@@ -0,0 +1,203 @@
+package sdl
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// playbackSpeeds are the frames per second that + and - step playback through.
+var playbackSpeeds = []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120, 240, 480, 960}
+
+// defaultSpeed is the index in playbackSpeeds that playback starts at.
+const defaultSpeed = 6
+
+// playback shows the frames of an event log in a window, one at a time.
+type playback struct {
+	l *gol.EventLog
+	w *Window
+	// shown is the world the window shows, which is that of frame.
+	shown   [][]byte
+	frame   int
+	playing bool
+	speed   int
+	// due is how many frames are due to be shown since the last tick, and jump the turn typed in to jump to.
+	due  float64
+	jump string
+}
+
+// RunPlayback shows a recorded run in the window, where it can be scrubbed through with no engine running.
+// Space or p plays and pauses, + and - change the speed, , and . step a frame back and forward,
+// digits then g jump to a turn, Home and End jump to the start and end, and h shows the HUD.
+// The board is zoomed and panned as in the live window.
+func RunPlayback(l *gol.EventLog) {
+	p := l.Params
+	w := NewWindow(int32(p.ImageWidth), int32(p.ImageHeight))
+	defer w.Destroy()
+	pb := &playback{l: l, w: w, shown: make([][]byte, p.ImageHeight), playing: true, speed: defaultSpeed}
+	for i := range pb.shown {
+		pb.shown[i] = make([]byte, p.ImageWidth)
+	}
+	pb.seek(0)
+	log.Printf("[Replay] %v frames from turn %v to %v", l.Frames(), l.Turn(0), l.Turn(l.Frames()-1))
+
+	view := &viewport{w: w}
+	showHUD, dirty := true, true
+	refreshTicker := time.NewTicker(time.Second / time.Duration(FPS))
+	defer refreshTicker.Stop()
+	last := time.Now()
+	for now := range refreshTicker.C {
+		for event := w.PollEvent(); event != nil; event = w.PollEvent() {
+			if view.handle(event) {
+				dirty = true
+				continue
+			}
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				switch e.Keysym.Sym {
+				case sdl.K_ESCAPE, sdl.K_q:
+					return
+				case sdl.K_h:
+					showHUD = !showHUD
+				default:
+					pb.key(e.Keysym.Sym)
+				}
+				dirty = true
+			}
+		}
+		if pb.playing {
+			pb.play(now.Sub(last))
+			dirty = true
+		}
+		last = now
+
+		if dirty {
+			if showHUD {
+				w.SetHUD(pb.lines())
+			} else {
+				w.SetHUD(nil)
+			}
+			w.RenderFrame()
+			dirty = false
+		}
+	}
+}
+
+// key acts on a key pressed during playback.
+func (pb *playback) key(key sdl.Keycode) {
+	end := pb.l.Frames() - 1
+	switch key {
+	case sdl.K_SPACE, sdl.K_p:
+		pb.playing = !pb.playing
+		pb.due = 0
+		// Playing from the end starts again from the beginning.
+		if pb.playing && pb.frame == end {
+			pb.seek(0)
+		}
+	case sdl.K_PLUS, sdl.K_EQUALS, sdl.K_KP_PLUS:
+		if pb.speed < len(playbackSpeeds)-1 {
+			pb.speed++
+		}
+	case sdl.K_MINUS, sdl.K_KP_MINUS:
+		if pb.speed > 0 {
+			pb.speed--
+		}
+	case sdl.K_COMMA:
+		pb.playing = false
+		pb.step(-1)
+	case sdl.K_PERIOD:
+		pb.playing = false
+		pb.step(1)
+	case sdl.K_HOME:
+		pb.seek(0)
+	case sdl.K_END:
+		pb.seek(end)
+	case sdl.K_0, sdl.K_1, sdl.K_2, sdl.K_3, sdl.K_4, sdl.K_5, sdl.K_6, sdl.K_7, sdl.K_8, sdl.K_9:
+		pb.jump += string(rune('0' + key - sdl.K_0))
+	case sdl.K_BACKSPACE:
+		if pb.jump != "" {
+			pb.jump = pb.jump[:len(pb.jump)-1]
+		}
+	case sdl.K_g, sdl.K_RETURN:
+		if turn, err := strconv.Atoi(pb.jump); err == nil {
+			pb.playing = false
+			pb.seek(pb.l.FrameAt(turn))
+		}
+		pb.jump = ""
+	}
+}
+
+// play shows the frames due after elapsed at the current speed, stopping at the end of the log.
+func (pb *playback) play(elapsed time.Duration) {
+	pb.due += playbackSpeeds[pb.speed] * elapsed.Seconds()
+	for ; pb.due >= 1; pb.due-- {
+		if pb.frame == pb.l.Frames()-1 {
+			pb.playing = false
+			pb.due = 0
+			return
+		}
+		pb.step(1)
+	}
+}
+
+// step moves one frame forward or back, flipping the cells that changed between the two.
+func (pb *playback) step(direction int) {
+	next := pb.frame + direction
+	if next < 0 || next >= pb.l.Frames() {
+		return
+	}
+	flips := pb.l.Flips(pb.frame)
+	if direction > 0 {
+		flips = pb.l.Flips(next)
+	}
+	pb.w.SetTurn(pb.l.Turn(next))
+	for _, cell := range flips {
+		pb.flip(cell.X, cell.Y)
+	}
+	pb.frame = next
+}
+
+// seek jumps to a frame, flipping the cells that differ from what is shown.
+func (pb *playback) seek(frame int) {
+	world := pb.l.World(frame)
+	pb.w.SetTurn(pb.l.Turn(frame))
+	for y := range world {
+		for x := range world[y] {
+			if world[y][x] != pb.shown[y][x] {
+				pb.flip(x, y)
+			}
+		}
+	}
+	pb.frame = frame
+	pb.due = 0
+}
+
+func (pb *playback) flip(x, y int) {
+	pb.shown[y][x] ^= 0xFF
+	pb.w.FlipPixel(x, y)
+}
+
+// lines returns the text of the HUD during playback.
+func (pb *playback) lines() []string {
+	state := "Paused"
+	if pb.playing {
+		state = "Playing"
+	}
+	lines := []string{
+		fmt.Sprintf("Turn      %v", pb.l.Turn(pb.frame)),
+		fmt.Sprintf("Frame     %v/%v", pb.frame, pb.l.Frames()-1),
+		fmt.Sprintf("Alive     %v", pb.w.Alive()),
+		fmt.Sprintf("Speed     %v frames/sec", playbackSpeeds[pb.speed]),
+		fmt.Sprintf("State     %v", state),
+	}
+	if pb.jump != "" {
+		lines = append(lines, fmt.Sprintf("Jump to   %v_", pb.jump))
+	}
+	return lines
+}
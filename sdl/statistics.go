@@ -0,0 +1,50 @@
+package sdl
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// statisticsWriter writes TurnStatistics events to a CSV file, one row per turn.
+// Turns skipped by fast-forwarding share a single row, with the number skipped in skipped_turns.
+type statisticsWriter struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+func newStatisticsWriter(path string) *statisticsWriter {
+	file, err := os.Create(path)
+	util.Check(err)
+	writer := csv.NewWriter(file)
+	err = writer.Write([]string{
+		"completed_turns", "population", "births", "deaths", "edited", "skipped_turns", "min_x", "min_y", "max_x", "max_y",
+	})
+	util.Check(err)
+	return &statisticsWriter{file, writer}
+}
+
+func (s *statisticsWriter) write(e gol.TurnStatistics) {
+	err := s.writer.Write([]string{
+		strconv.Itoa(e.CompletedTurns),
+		strconv.Itoa(e.Population),
+		strconv.Itoa(e.Births),
+		strconv.Itoa(e.Deaths),
+		strconv.Itoa(e.Edited),
+		strconv.Itoa(e.Skipped),
+		strconv.Itoa(e.TopLeft.X),
+		strconv.Itoa(e.TopLeft.Y),
+		strconv.Itoa(e.BottomRight.X),
+		strconv.Itoa(e.BottomRight.Y),
+	})
+	util.Check(err)
+}
+
+func (s *statisticsWriter) close() {
+	s.writer.Flush()
+	util.Check(s.writer.Error())
+	util.Check(s.file.Close())
+}
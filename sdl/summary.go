@@ -0,0 +1,87 @@
+package sdl
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// summary is the machine-readable result of a headless run, written as JSON when it ends.
+type summary struct {
+	Params gol.Params `json:"params"`
+	Engine struct {
+		Name      string   `json:"name"`
+		Broker    string   `json:"broker"`
+		Workers   int      `json:"workers"`
+		Addresses []string `json:"workerAddresses"`
+	} `json:"engine"`
+	Started     time.Time `json:"started"`
+	Finished    time.Time `json:"finished"`
+	WallSeconds float64   `json:"wallSeconds"`
+	// StartTurn and CompletedTurns bound the turns run. Both rates are measured by the same util.AvgTurns:
+	// the average over the whole run, and the peak as the highest of its rolling averages sampled with the alive cells count
+	// every 2 seconds, left out for runs that end before it is first sampled.
+	StartTurn          int      `json:"startTurn"`
+	CompletedTurns     int      `json:"completedTurns"`
+	AverageTurnsPerSec float64  `json:"averageTurnsPerSec"`
+	PeakTurnsPerSec    int      `json:"peakTurnsPerSec,omitempty"`
+	FinalPopulation    int      `json:"finalPopulation"`
+	StablePeriod       int      `json:"stablePeriod,omitempty"`
+	Outputs            []string `json:"outputs"`
+}
+
+// summaryWriter collects the summary of a run from its events.
+type summaryWriter struct {
+	path    string
+	summary summary
+	turns   *util.AvgTurns
+}
+
+// newSummaryWriter collects the summary of a run whose rates are measured by turns.
+func newSummaryWriter(path string, p gol.Params, turns *util.AvgTurns) *summaryWriter {
+	s := &summaryWriter{path: path, turns: turns}
+	s.summary.Params = p
+	s.summary.Started = time.Now()
+	s.summary.StartTurn = p.StartTurn
+	s.summary.CompletedTurns = p.StartTurn
+	s.summary.Outputs = []string{}
+	return s
+}
+
+// update records what an event says about the run.
+func (s *summaryWriter) update(event gol.Event) {
+	switch e := event.(type) {
+	case gol.ImageOutputComplete:
+		s.summary.Outputs = append(s.summary.Outputs, s.summary.Params.OutPath(e.Filename))
+	case gol.StabilityDetected:
+		s.summary.StablePeriod = e.Period
+	case gol.EngineDetails:
+		s.summary.Engine.Name = "broker"
+		s.summary.Engine.Broker = e.Broker
+		s.summary.Engine.Workers = len(e.Workers)
+		s.summary.Engine.Addresses = e.Workers
+	case gol.FinalTurnComplete:
+		s.summary.CompletedTurns = e.CompletedTurns
+		s.summary.FinalPopulation = len(e.Alive)
+	}
+}
+
+// rate records the turns per second logged with an AliveCellsCount, keeping the peak.
+func (s *summaryWriter) rate(turnsPerSec int) {
+	if turnsPerSec > s.summary.PeakTurnsPerSec {
+		s.summary.PeakTurnsPerSec = turnsPerSec
+	}
+}
+
+// write finishes the summary and writes it to its file.
+func (s *summaryWriter) write() {
+	s.summary.Finished = time.Now()
+	s.summary.WallSeconds = s.summary.Finished.Sub(s.summary.Started).Seconds()
+	s.summary.AverageTurnsPerSec = s.turns.Average(s.summary.CompletedTurns)
+	data, err := json.MarshalIndent(s.summary, "", "  ")
+	util.Check(err)
+	util.Check(os.WriteFile(s.path, append(data, '\n'), 0644))
+}
@@ -0,0 +1,312 @@
+package sdl
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// terminalFPS is how often the terminal is redrawn at most.
+const terminalFPS = 20
+
+// logLines is how many of the latest log lines are shown below the board.
+const logLines = 3
+
+// Keys read from the terminal that are handled by the viewer itself rather than sent to gol.
+const (
+	keyUp rune = -1 - iota
+	keyDown
+	keyRight
+	keyLeft
+)
+
+// terminal is a live view of the board drawn in the terminal with ANSI escape codes.
+type terminal struct {
+	p     gol.Params
+	world [][]byte
+	alive int
+	turn  int
+	state gol.State
+	// braille packs 2x4 cells into each character instead of 1x2 with half blocks.
+	braille bool
+	// left and top are the cell at the top left corner of the viewport.
+	left, top int
+	// columns and rows are the size of the terminal in characters, checked at most once a second.
+	columns, rows int
+	sized         time.Time
+	logs          *logCapture
+	out           *bufio.Writer
+}
+
+// logCapture keeps the log while the terminal is taken over by the board, to be shown below it and printed after.
+type logCapture struct {
+	sync.Mutex
+	lines []string
+}
+
+func (l *logCapture) Write(b []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+	l.lines = append(l.lines, strings.TrimRight(string(b), "\n"))
+	return len(b), nil
+}
+
+func (l *logCapture) last(n int) []string {
+	l.Lock()
+	defer l.Unlock()
+	if len(l.lines) < n {
+		return append([]string(nil), l.lines...)
+	}
+	return append([]string(nil), l.lines[len(l.lines)-n:]...)
+}
+
+// RunTerminal shows the board live in the terminal, for machines without a display.
+// Keys are read from stdin in raw mode: p, s, q, k, + and - are sent to gol,
+// the arrow keys pan over boards larger than the terminal and b switches between half blocks and braille.
+func RunTerminal(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
+	t := &terminal{
+		p:       p,
+		world:   make([][]byte, p.ImageHeight),
+		logs:    new(logCapture),
+		out:     bufio.NewWriterSize(os.Stdout, 1<<16),
+		columns: 80,
+		rows:    24,
+	}
+	for i := range t.world {
+		t.world[i] = make([]byte, p.ImageWidth)
+	}
+
+	restore := rawMode()
+	log.SetOutput(t.logs)
+	// Draw on the alternate screen with the cursor hidden, putting everything back when done.
+	fmt.Fprint(os.Stdout, "\033[?1049h\033[?25l")
+	defer func() {
+		fmt.Fprint(os.Stdout, "\033[?25h\033[?1049l")
+		restore()
+		log.SetOutput(os.Stderr)
+		for _, line := range t.logs.lines {
+			fmt.Fprintln(os.Stderr, line)
+		}
+	}()
+
+	keys := make(chan rune, 10)
+	go readKeys(keys)
+	avgTurns := util.NewAvgTurnsFrom(p.StartTurn)
+	refreshTicker := time.NewTicker(time.Second / terminalFPS)
+	defer refreshTicker.Stop()
+	dirty := true
+
+terminal:
+	for {
+		select {
+		case <-refreshTicker.C:
+			if dirty || time.Since(t.sized) > time.Second {
+				t.render()
+				dirty = false
+			}
+
+		case key := <-keys:
+			switch key {
+			case 'p', 's', 'q', 'k', 'g', ',', '.', '+', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				keyPresses <- key
+			case 'b':
+				t.braille = !t.braille
+			case keyUp, keyDown, keyLeft, keyRight:
+				t.pan(key)
+			}
+			dirty = true
+
+		case event, ok := <-events:
+			if !ok {
+				break terminal
+			}
+			switch e := event.(type) {
+			case gol.CellFlipped:
+				t.flip(e.Cell)
+			case gol.CellsFlipped:
+				for _, cell := range e.Cells {
+					t.flip(cell)
+				}
+			case gol.TurnComplete:
+				t.turn = e.CompletedTurns
+				dirty = true
+			case gol.AliveCellsCount:
+				log.Printf(
+					"[Event] Completed Turns %-8v %-20v Avg%+5v turns/sec\n",
+					event.GetCompletedTurns(),
+					event,
+					avgTurns.TurnsPerSec(event.GetCompletedTurns()),
+				)
+			case gol.FinalTurnComplete, gol.StabilityDetected, gol.ImageOutputComplete, gol.TurnRateChanged, gol.EngineDetails:
+				log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
+			case gol.StateChange:
+				log.Printf("[Event] Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
+				t.turn = e.CompletedTurns
+				t.state = e.NewState
+				dirty = true
+				if e.NewState == gol.Quitting {
+					break terminal
+				}
+			}
+		}
+	}
+}
+
+func (t *terminal) flip(cell util.Cell) {
+	t.world[cell.Y][cell.X] = ^t.world[cell.Y][cell.X]
+	if t.world[cell.Y][cell.X] == 0xFF {
+		t.alive++
+	} else {
+		t.alive--
+	}
+}
+
+// view returns the number of cells across and down the terminal shows, leaving room for the status and log lines.
+func (t *terminal) view() (int, int) {
+	columns, rows := t.columns, t.rows-1-logLines
+	if rows < 1 {
+		rows = 1
+	}
+	if t.braille {
+		return 2 * columns, 4 * rows
+	}
+	return columns, 2 * rows
+}
+
+// pan moves the viewport a quarter of the terminal in the direction of the arrow key.
+func (t *terminal) pan(key rune) {
+	width, height := t.view()
+	switch key {
+	case keyUp:
+		t.top -= height / 4
+	case keyDown:
+		t.top += height / 4
+	case keyLeft:
+		t.left -= width / 4
+	case keyRight:
+		t.left += width / 4
+	}
+}
+
+// clampView keeps the viewport over the board, centring the board when it is smaller than the terminal.
+func clampView(start, view, size int) int {
+	if view >= size {
+		return (size - view) / 2
+	}
+	if start < 0 {
+		return 0
+	}
+	if start > size-view {
+		return size - view
+	}
+	return start
+}
+
+func (t *terminal) render() {
+	if time.Since(t.sized) > time.Second {
+		t.columns, t.rows = terminalSize(t.columns, t.rows)
+		t.sized = time.Now()
+	}
+	width, height := t.view()
+	t.left = clampView(t.left, width, t.p.ImageWidth)
+	t.top = clampView(t.top, height, t.p.ImageHeight)
+
+	var lines []string
+	if t.braille {
+		lines = util.Braille(t.world, t.left, t.top, t.columns, height/4)
+	} else {
+		lines = util.HalfBlocks(t.world, t.left, t.top, t.columns, height/2)
+	}
+
+	t.out.WriteString("\033[H")
+	for _, line := range lines {
+		t.out.WriteString(line)
+		t.out.WriteString("\033[K\r\n")
+	}
+	status := fmt.Sprintf(
+		"Turn %v  Alive %v  %v  View %v,%v %vx%v of %vx%v  [arrows pan, b braille]",
+		t.turn, t.alive, t.state, t.left, t.top, width, height, t.p.ImageWidth, t.p.ImageHeight,
+	)
+	t.out.WriteString("\033[7m" + truncate(status, t.columns) + "\033[K\033[0m")
+	for _, line := range t.logs.last(logLines) {
+		t.out.WriteString("\r\n" + truncate(line, t.columns) + "\033[K")
+	}
+	t.out.WriteString("\033[J")
+	if err := t.out.Flush(); err != nil {
+		log.Printf("[Terminal] %v Failed to draw: %v", util.Red("ERROR"), err)
+	}
+}
+
+func truncate(line string, columns int) string {
+	if runes := []rune(line); len(runes) > columns {
+		return string(runes[:columns])
+	}
+	return line
+}
+
+// readKeys sends the keys typed into stdin, translating the escape sequences of the arrow keys.
+func readKeys(keys chan<- rune) {
+	in := bufio.NewReader(os.Stdin)
+	arrows := map[byte]rune{'A': keyUp, 'B': keyDown, 'C': keyRight, 'D': keyLeft}
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return
+		}
+		if b != '\033' {
+			keys <- rune(b)
+			continue
+		}
+		if next, err := in.ReadByte(); err != nil || next != '[' {
+			continue
+		}
+		if final, err := in.ReadByte(); err == nil && arrows[final] != 0 {
+			keys <- arrows[final]
+		}
+	}
+}
+
+// stty runs stty on the terminal attached to stdin.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// rawMode makes stdin send each key as it is typed without echoing it, returning a function that undoes it.
+// Signals are left on, so that Ctrl+C still reaches gol.
+func rawMode() func() {
+	saved, err := stty("-g")
+	if err == nil {
+		_, err = stty("-icanon", "-echo", "min", "1")
+	}
+	if err != nil {
+		log.Printf("[Terminal] %v Keys need Enter, stdin is not a terminal: %v", util.Yellow("WARN"), err)
+		return func() {}
+	}
+	return func() {
+		_, _ = stty(saved)
+	}
+}
+
+// terminalSize returns the size of the terminal in characters, or the given size if it cannot be found.
+func terminalSize(columns, rows int) (int, int) {
+	out, err := stty("size")
+	if err != nil {
+		return columns, rows
+	}
+	var c, r int
+	if _, err := fmt.Sscan(out, &r, &c); err != nil || c <= 0 || r <= 0 {
+		return columns, rows
+	}
+	return c, r
+}
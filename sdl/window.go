@@ -2,72 +2,218 @@ package sdl
 
 import (
 	"fmt"
+	"image"
+	"math"
 	"unsafe"
 
 	"github.com/veandco/go-sdl2/sdl"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
+// Window shows the board through a viewport that can be zoomed and panned.
+// The pixels of the board are kept at one pixel per cell, and are scaled to the size of the window when rendered:
+// by nearest neighbour when zoomed in, and by the density of alive cells when zoomed out.
 type Window struct {
 	Width, Height int32
 	window        *sdl.Window
 	renderer      *sdl.Renderer
 	texture       *sdl.Texture
 	pixels        []byte
+	alive         int
+
+	// view holds the pixels of the window, which is viewWidth x viewHeight pixels.
+	view                  []byte
+	viewWidth, viewHeight int32
+	// zoom is the number of window pixels per cell and left, top the cell at the top left corner of the window.
+	zoom      float64
+	left, top float64
+	// fitted is set until the viewport is moved, so that resizing the window fits the board to it again.
+	fitted bool
+
+	// mode is how cells are coloured. Except in Plain mode, changed holds the turn each cell last flipped.
+	mode    RenderMode
+	turn    int
+	changed []int64
+
+	// overlay holds the lines of the HUD drawn over the board, if any.
+	overlay []string
+	// selection is the rectangle of cells outlined over the board in draw mode, if it is not empty.
+	selection image.Rectangle
 }
 
+const (
+	// minWindowSize and maxWindowSize bound the longer side of a new window.
+	minWindowSize = 512
+	maxWindowSize = 1024
+	maxZoom       = 64
+)
+
+// background is the colour of the window outside the board.
+const background = 0x30
+
 func filterEvent(e sdl.Event, userdata interface{}) bool {
-	return e.GetType() == sdl.KEYDOWN || e.GetType() == sdl.QUIT
+	switch e.GetType() {
+	case sdl.KEYDOWN, sdl.MOUSEBUTTONDOWN, sdl.MOUSEBUTTONUP, sdl.MOUSEMOTION, sdl.MOUSEWHEEL, sdl.WINDOWEVENT, sdl.QUIT:
+		return true
+	}
+	return false
 }
 
 func NewWindow(width, height int32) *Window {
 	err := sdl.Init(sdl.INIT_EVERYTHING)
 	util.Check(err)
+	// Small boards start zoomed in and large boards zoomed out, to fit the screen.
+	zoom := 1.0
+	if longest := float64(max32(width, height)); longest < minWindowSize {
+		zoom = math.Floor(minWindowSize / longest)
+	} else if longest > maxWindowSize {
+		zoom = maxWindowSize / longest
+	}
 	window, err := sdl.CreateWindow(
 		"GOL GUI",
 		sdl.WINDOWPOS_CENTERED,
 		sdl.WINDOWPOS_CENTERED,
-		width,
-		height,
-		sdl.WINDOW_SHOWN,
+		max32(int32(float64(width)*zoom), 1),
+		max32(int32(float64(height)*zoom), 1),
+		sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE,
 	)
 	util.Check(err)
 	renderer, err := sdl.CreateRenderer(window, -1, sdl.WINDOW_SHOWN)
 	util.Check(err)
-	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "linear")
-	err = renderer.SetLogicalSize(width, height)
-	util.Check(err)
-	texture, err := renderer.CreateTexture(
-		sdl.PIXELFORMAT_ARGB8888,
-		sdl.TEXTUREACCESS_STATIC,
-		width,
-		height,
-	)
-	util.Check(err)
 
 	sdl.SetEventFilterFunc(filterEvent, nil)
-	return &Window{
-		width,
-		height,
-		window,
-		renderer,
-		texture,
-		make([]byte, width*height*4),
+	w := &Window{
+		Width:    width,
+		Height:   height,
+		window:   window,
+		renderer: renderer,
+		pixels:   make([]byte, width*height*4),
 	}
+	w.resize()
+	w.Fit()
+	return w
 }
 
 func (w *Window) Destroy() {
-	err := w.texture.Destroy()
-	util.Check(err)
-	err = w.renderer.Destroy()
+	if w.texture != nil {
+		err := w.texture.Destroy()
+		util.Check(err)
+	}
+	err := w.renderer.Destroy()
 	util.Check(err)
 	err = w.window.Destroy()
 	util.Check(err)
 	sdl.Quit()
 }
 
+// resize matches the view and its texture to the size of the window, reporting whether it changed.
+func (w *Window) resize() bool {
+	width, height, err := w.renderer.GetOutputSize()
+	util.Check(err)
+	width, height = max32(width, 1), max32(height, 1)
+	if w.texture != nil && width == w.viewWidth && height == w.viewHeight {
+		return false
+	}
+	if w.texture != nil {
+		err = w.texture.Destroy()
+		util.Check(err)
+	}
+	w.texture, err = w.renderer.CreateTexture(
+		sdl.PIXELFORMAT_ARGB8888,
+		sdl.TEXTUREACCESS_STREAMING,
+		width,
+		height,
+	)
+	util.Check(err)
+	w.viewWidth, w.viewHeight = width, height
+	w.view = make([]byte, width*height*4)
+	if w.fitted {
+		w.Fit()
+	}
+	return true
+}
+
+// Fit zooms and centres the viewport so that the whole board fits the window.
+func (w *Window) Fit() {
+	w.zoom = w.fitZoom()
+	w.left = float64(w.Width)/2 - float64(w.viewWidth)/2/w.zoom
+	w.top = float64(w.Height)/2 - float64(w.viewHeight)/2/w.zoom
+	w.fitted = true
+}
+
+func (w *Window) fitZoom() float64 {
+	return math.Min(float64(w.viewWidth)/float64(w.Width), float64(w.viewHeight)/float64(w.Height))
+}
+
+// ZoomAt multiplies the zoom by factor, keeping the cell under the window position (x, y) in place.
+// The board can be zoomed out to half the size that fits the window, and in to maxZoom pixels per cell.
+func (w *Window) ZoomAt(factor float64, x, y int32) {
+	px, py := w.toView(x, y)
+	cellX, cellY := w.left+px/w.zoom, w.top+py/w.zoom
+	w.zoom = math.Max(math.Min(w.zoom*factor, math.Max(maxZoom, w.fitZoom())), w.fitZoom()/2)
+	w.left, w.top = cellX-px/w.zoom, cellY-py/w.zoom
+	w.fitted = false
+	w.clamp()
+}
+
+// Zoom multiplies the zoom by factor about the centre of the window.
+func (w *Window) Zoom(factor float64) {
+	width, height := w.window.GetSize()
+	w.ZoomAt(factor, width/2, height/2)
+}
+
+// Pan moves the board by (dx, dy) window positions.
+func (w *Window) Pan(dx, dy int32) {
+	px, py := w.toView(dx, dy)
+	w.left -= px / w.zoom
+	w.top -= py / w.zoom
+	w.fitted = false
+	w.clamp()
+}
+
+// PanView moves the board by a fraction of the window.
+func (w *Window) PanView(fx, fy float64) {
+	width, height := w.window.GetSize()
+	w.Pan(int32(fx*float64(width)), int32(fy*float64(height)))
+}
+
+// clamp keeps the centre of the window over the board.
+func (w *Window) clamp() {
+	halfWidth, halfHeight := float64(w.viewWidth)/2/w.zoom, float64(w.viewHeight)/2/w.zoom
+	w.left = math.Max(math.Min(w.left, float64(w.Width)-halfWidth), -halfWidth)
+	w.top = math.Max(math.Min(w.top, float64(w.Height)-halfHeight), -halfHeight)
+}
+
+// toView converts window positions, as in mouse events, to pixels of the view, which differ on high-DPI screens.
+func (w *Window) toView(x, y int32) (float64, float64) {
+	width, height := w.window.GetSize()
+	if width <= 0 || height <= 0 {
+		return float64(x), float64(y)
+	}
+	return float64(x) * float64(w.viewWidth) / float64(width), float64(y) * float64(w.viewHeight) / float64(height)
+}
+
+// Cell returns the cell at the window position (x, y), and whether it is on the board.
+func (w *Window) Cell(x, y int32) (util.Cell, bool) {
+	px, py := w.toView(x, y)
+	cell := util.Cell{
+		X: int(math.Floor(w.left + px/w.zoom)),
+		Y: int(math.Floor(w.top + py/w.zoom)),
+	}
+	return cell, cell.X >= 0 && cell.Y >= 0 && cell.X < int(w.Width) && cell.Y < int(w.Height)
+}
+
+// RenderFrame draws the viewport of the board to the window.
 func (w *Window) RenderFrame() {
-	err := w.texture.Update(nil, unsafe.Pointer(&w.pixels[0]), int(w.Width*4))
+	w.resize()
+	if w.zoom >= 1 {
+		w.renderNearest()
+	} else {
+		w.renderDensity()
+	}
+	w.drawSelection()
+	w.drawHUD()
+	err := w.texture.Update(nil, unsafe.Pointer(&w.view[0]), int(w.viewWidth*4))
 	util.Check(err)
 	err = w.renderer.Clear()
 	util.Check(err)
@@ -76,12 +222,97 @@ func (w *Window) RenderFrame() {
 	w.renderer.Present()
 }
 
+// renderNearest scales the board up, with each pixel of the view taking the colour of the cell under it.
+func (w *Window) renderNearest() {
+	width, height := int(w.Width), int(w.Height)
+	columns := make([]int, w.viewWidth)
+	for px := range columns {
+		columns[px] = int(math.Floor(w.left + (float64(px)+0.5)/w.zoom))
+	}
+	i := 0
+	for py := 0; py < int(w.viewHeight); py++ {
+		y := int(math.Floor(w.top + (float64(py)+0.5)/w.zoom))
+		for _, x := range columns {
+			if x < 0 || y < 0 || x >= width || y >= height {
+				setGrey(w.view[i:i+4], background)
+			} else {
+				c := w.cellColour(y*width + x)
+				copy(w.view[i:i+4], c[:])
+			}
+			i += 4
+		}
+	}
+}
+
+// renderDensity scales the board down, with each pixel of the view the average colour of the cells under it.
+// In Plain mode, that is as bright as the share of alive cells.
+func (w *Window) renderDensity() {
+	width, height := int(w.Width), int(w.Height)
+	// Each pixel covers the cells from bounds[p] up to bounds[p+1], clipped to the board.
+	bounds := func(start float64, pixels, cells int) []int {
+		b := make([]int, pixels+1)
+		for p := range b {
+			b[p] = clamp(int(math.Floor(start+float64(p)/w.zoom)), 0, cells)
+		}
+		return b
+	}
+	columns := bounds(w.left, int(w.viewWidth), width)
+	rows := bounds(w.top, int(w.viewHeight), height)
+	i := 0
+	for py := 0; py < int(w.viewHeight); py++ {
+		for px := 0; px < int(w.viewWidth); px++ {
+			x0, x1, y0, y1 := columns[px], columns[px+1], rows[py], rows[py+1]
+			if x0 == x1 || y0 == y1 {
+				setGrey(w.view[i:i+4], background)
+				i += 4
+				continue
+			}
+			var sum [3]int
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					c := w.cellColour(y*width + x)
+					sum[0] += int(c[0])
+					sum[1] += int(c[1])
+					sum[2] += int(c[2])
+				}
+			}
+			cells := (x1 - x0) * (y1 - y0)
+			w.view[i], w.view[i+1], w.view[i+2], w.view[i+3] = byte(sum[0]/cells), byte(sum[1]/cells), byte(sum[2]/cells), 0xFF
+			i += 4
+		}
+	}
+}
+
+func setGrey(pixel []byte, grey byte) {
+	pixel[0], pixel[1], pixel[2], pixel[3] = grey, grey, grey, 0xFF
+}
+
+func clamp(n, low, high int) int {
+	if n < low {
+		return low
+	}
+	if n > high {
+		return high
+	}
+	return n
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 func (w *Window) PollEvent() sdl.Event {
 	return sdl.PollEvent()
 }
 
 func (w *Window) SetPixel(x, y int) {
 	width := int(w.Width)
+	if w.pixels[4*(y*width+x)] != 0xFF {
+		w.alive++
+	}
 	w.pixels[4*(y*width+x)+0] = 0xFF
 	w.pixels[4*(y*width+x)+1] = 0xFF
 	w.pixels[4*(y*width+x)+2] = 0xFF
@@ -98,6 +329,14 @@ func (w *Window) FlipPixel(x, y int) {
 	}
 
 	width := int(w.Width)
+	if w.changed != nil {
+		w.changed[y*width+x] = int64(w.turn)
+	}
+	if w.pixels[4*(y*width+x)] == 0xFF {
+		w.alive--
+	} else {
+		w.alive++
+	}
 	w.pixels[4*(y*width+x)+0] = ^w.pixels[4*(y*width+x)+0]
 	w.pixels[4*(y*width+x)+1] = ^w.pixels[4*(y*width+x)+1]
 	w.pixels[4*(y*width+x)+2] = ^w.pixels[4*(y*width+x)+2]
@@ -114,7 +353,13 @@ func (w *Window) CountPixels() int {
 	return count
 }
 
+// Alive returns the number of alive cells on the board, as CountPixels does without counting them.
+func (w *Window) Alive() int {
+	return w.alive
+}
+
 func (w *Window) ClearPixels() {
+	w.alive = 0
 	for i := range w.pixels {
 		w.pixels[i] = 0
 	}
@@ -11,6 +11,11 @@ var (
 	CountAliveHandler   = "Broker.CountAlive"
 	CurrentStateHandler = "Broker.CurrentState"
 	PauseHandler        = "Broker.Pause"
+	SeekHandler         = "Broker.Seek"
+	EditCellsHandler    = "Broker.EditCells"
+	StatisticsHandler   = "Broker.Statistics"
+	GenerationsHandler  = "Broker.Generations"
+	TurnRateHandler     = "Broker.SetTurnRate"
 	BrokerCloseHandler  = "Broker.Close"
 
 	RunWorldHandler    = "GOLOperations.RunWorld"
@@ -23,7 +28,9 @@ type SubscribeRequest struct {
 	Address string
 }
 
-type PreBreakResponse struct{}
+type PreBreakResponse struct {
+	Amendments int
+}
 
 type PreBreakRequest struct{}
 
@@ -31,14 +38,22 @@ type BreakWorldResponse struct {
 	CompletedTurns int
 	World          [][]byte
 	AliveCells     []util.Cell
+	StableTurns    int
+	Period         int
+	// Workers are the addresses of the workers that computed the run.
+	Workers []string
 }
 
 type BreakWorldRequest struct {
-	Turns       int
-	Threads     int
-	ImageWidth  int
-	ImageHeight int
-	World       [][]byte
+	Turns          int
+	CompletedTurns int
+	Threads        int
+	ImageWidth     int
+	ImageHeight    int
+	World          [][]byte
+	FastForward    bool
+	Statistics     bool
+	TurnRate       int
 }
 
 type RunWorldResponse struct {
@@ -54,6 +69,8 @@ type RunWorldRequest struct {
 type CountAliveResponse struct {
 	CompletedTurns int
 	CellsCount     int
+	StableTurns    int
+	Period         int
 }
 
 type CountAliveRequest struct{}
@@ -65,6 +82,72 @@ type CurrentStateResponse struct {
 
 type CurrentStateRequest struct{}
 
+type SeekResponse struct {
+	CompletedTurns int
+	World          [][]byte
+}
+
+type SeekRequest struct {
+	Turn int
+}
+
+type EditCellsResponse struct{}
+
+type EditCellsRequest struct {
+	Set     []util.Cell
+	Clear   []util.Cell
+	Pattern [][]byte
+	Offset  util.Cell
+}
+
+type TurnStatistics struct {
+	CompletedTurns int
+	Births         int
+	Deaths         int
+	Edited         int
+	Skipped        int
+	Population     int
+	TopLeft        util.Cell
+	BottomRight    util.Cell
+}
+
+type StatisticsResponse struct {
+	Turns []TurnStatistics
+}
+
+type StatisticsRequest struct{}
+
+// Generation is the change leading to the generation at CompletedTurns.
+// It is either the whole world bit-packed as a Keyframe or the indices of the cells that flipped since the previous turn.
+type Generation struct {
+	CompletedTurns int
+	Keyframe       []byte
+	Flips          []uint32
+}
+
+type GenerationsResponse struct {
+	Generations []Generation
+	Amendments  int
+}
+
+// GenerationsRequest asks for up to Limit of the generations after From.
+// Amendments is the count of edits seen so far, as given by PreBreak at the start of the run.
+// If it is out of date the response is a keyframe of the latest generation instead.
+type GenerationsRequest struct {
+	From       int
+	Amendments int
+	Limit      int
+}
+
+// TurnRateRequest limits the broker to TurnRate turns per second, or none with a TurnRate of 0.
+type TurnRateRequest struct {
+	TurnRate int
+}
+
+type TurnRateResponse struct {
+	CompletedTurns int
+}
+
 type PauseResponse struct{}
 
 type PauseRequest struct{}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestAutosave saves a snapshot every 10 turns and at turn 25, keeping the last two and the first of every 50 turns.
+func TestAutosave(t *testing.T) {
+	p := gol.Params{
+		Turns:             100,
+		Threads:           8,
+		ImageWidth:        64,
+		ImageHeight:       64,
+		SnapshotEvery:     10,
+		SnapshotTurns:     []int{25},
+		SnapshotKeep:      2,
+		SnapshotKeepEvery: 50,
+	}
+	emptyOutFolder()
+	runFinal(t, p)
+
+	paths, err := filepath.Glob("out/*.snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var turns []int
+	for _, path := range paths {
+		s, err := util.LoadSnapshot(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		turns = append(turns, s.Turn)
+	}
+	sort.Ints(turns)
+	expected := []int{10, 50, 90, 100}
+	assert(t, len(turns) == len(expected), "Expected snapshots of turns %v, got %v instead", expected, turns)
+	for i := range expected {
+		assert(t, turns[i] == expected[i], "Expected snapshots of turns %v, got %v instead", expected, turns)
+	}
+
+	s, err := util.LoadSnapshot("out/64x64x50.snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Turns = 50
+	p.SnapshotEvery = 0
+	p.SnapshotTurns = nil
+	var cells []util.Cell
+	for y, row := range s.World {
+		for x, cell := range row {
+			if cell == 255 {
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	assertEqualBoard(t, cells, runFinal(t, p), p)
+}
+
+// TestAutosaveSkipped fast-forwards a glider over a turn due to be saved,
+// which is then saved as the first turn after it that the broker computes.
+func TestAutosaveSkipped(t *testing.T) {
+	p := gol.Params{
+		Turns:         1000,
+		Threads:       8,
+		ImageWidth:    16,
+		ImageHeight:   16,
+		FastForward:   true,
+		SnapshotTurns: []int{500},
+	}
+	emptyOutFolder()
+	runFinal(t, p)
+
+	paths, err := filepath.Glob("out/*.snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("Expected a snapshot for turn 500, got %v", paths)
+	}
+	s, err := util.LoadSnapshot(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, s.Turn >= 500 && s.Turn <= p.Turns, "Expected a snapshot of a turn from 500 to %v, got turn %v", p.Turns, s.Turn)
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"image"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// newSketch starts a sketch of an empty width x height board, keeping the board as flipped by the sketch
+// so that it can be checked that every change is flipped.
+func newSketch(width, height int) (*gol.Sketch, [][]byte) {
+	world := make([][]byte, height)
+	for i := range world {
+		world[i] = make([]byte, width)
+	}
+	flipped := make([][]byte, height)
+	for i := range flipped {
+		flipped[i] = make([]byte, width)
+	}
+	return gol.NewSketch(world, func(x, y int) { flipped[y][x] ^= 0xFF }), flipped
+}
+
+// sketchCells returns the alive cells of a sketch.
+func sketchCells(s *gol.Sketch) []util.Cell {
+	var cells []util.Cell
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			if s.Alive(x, y) {
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return cells
+}
+
+// TestSketch edits boards as draw mode does, checking the cells it sets, selects, pastes and rotates
+// and the edits it sends to the engine.
+func TestSketch(t *testing.T) {
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16}
+
+	t.Run("edits", func(t *testing.T) {
+		world := make([][]byte, 16)
+		for i := range world {
+			world[i] = make([]byte, 16)
+		}
+		world[9][9] = 0xFF
+		s := gol.NewSketch(world, func(x, y int) {})
+		s.Set(3, 3, 0xFF)
+		s.Line(util.Cell{X: 3, Y: 3}, util.Cell{X: 6, Y: 3}, 0xFF)
+		s.Set(5, 3, 0)
+		s.Set(9, 9, 0)
+		s.Set(0, 0, 0xFF)
+		s.Set(0, 0, 0)
+		set, clear := s.Edits()
+		assertEqualBoard(t, set, []util.Cell{{X: 3, Y: 3}, {X: 4, Y: 3}, {X: 6, Y: 3}}, p)
+		assertEqualBoard(t, clear, []util.Cell{{X: 9, Y: 9}}, p)
+		assert(t, world[9][9] == 0xFF, "Expected the sketch not to change the world it was started from")
+	})
+
+	t.Run("put", func(t *testing.T) {
+		s, flipped := newSketch(16, 16)
+		block := [][]byte{{0xFF, 0xFF}, {0xFF, 0xFF}}
+		s.Put(block, util.Cell{X: 15, Y: 15})
+		corners := []util.Cell{{X: 0, Y: 0}, {X: 15, Y: 0}, {X: 0, Y: 15}, {X: 15, Y: 15}}
+		assertEqualBoard(t, sketchCells(s), corners, p)
+		assert(t, s.Selection == image.Rect(15, 15, 16, 16), "Expected the part of the paste on the board to be selected, got %v", s.Selection)
+		assertEqualBoard(t, aliveCells(flipped), corners, p)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		s, _ := newSketch(16, 16)
+		glider := [][]byte{{0, 0xFF, 0}, {0, 0, 0xFF}, {0xFF, 0xFF, 0xFF}}
+		s.Put(glider, util.Cell{X: 2, Y: 2})
+		s.Select(image.Rect(2, 2, 5, 5))
+		copied := s.Selected()
+		assert(t, reflect.DeepEqual(copied, glider), "Expected the selection to copy %v, got %v", glider, copied)
+		s.Fill(func() byte { return 0 })
+		s.Put(copied, util.Cell{X: 10, Y: 1})
+		assertEqualBoard(t, sketchCells(s), []util.Cell{{X: 11, Y: 1}, {X: 12, Y: 2}, {X: 10, Y: 3}, {X: 11, Y: 3}, {X: 12, Y: 3}}, p)
+	})
+
+	t.Run("rotate", func(t *testing.T) {
+		s, flipped := newSketch(16, 16)
+		line := [][]byte{{0xFF, 0xFF, 0xFF}, {0, 0, 0xFF}}
+		s.Put(line, util.Cell{X: 4, Y: 4})
+		s.Rotate()
+		expected := []util.Cell{{X: 5, Y: 4}, {X: 5, Y: 5}, {X: 4, Y: 6}, {X: 5, Y: 6}}
+		assertEqualBoard(t, sketchCells(s), expected, p)
+		assert(t, s.Selection == image.Rect(4, 4, 6, 7), "Expected the rotated cells to be selected, got %v", s.Selection)
+		assertEqualBoard(t, aliveCells(flipped), expected, p)
+	})
+}
+
+// TestCatchesUp checks which events show that the engine has caught up with cells drawn at a turn.
+func TestCatchesUp(t *testing.T) {
+	cases := []struct {
+		event    gol.Event
+		expected bool
+	}{
+		{gol.CellsFlipped{CompletedTurns: 5, Cells: []util.Cell{{X: 1, Y: 1}}}, true},
+		{gol.CellFlipped{CompletedTurns: 5, Cell: util.Cell{X: 1, Y: 1}}, true},
+		{gol.TurnComplete{CompletedTurns: 5}, false},
+		{gol.TurnComplete{CompletedTurns: 6}, true},
+		{gol.StateChange{CompletedTurns: 6, NewState: gol.Executing}, false},
+	}
+	for _, c := range cases {
+		assert(t, gol.CatchesUp(c.event, 5) == c.expected, "Expected CatchesUp of %v at turn 5 to be %v", c.event, c.expected)
+	}
+}
+
+// TestSaveSelection saves a selection as an RLE pattern and checks that it loads back the same.
+func TestSaveSelection(t *testing.T) {
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, OutDir: t.TempDir()}
+	glider := [][]byte{
+		{0, 255, 0},
+		{0, 0, 255},
+		{255, 255, 255},
+	}
+	path, err := p.SaveSelection(glider, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, path == filepath.Join(p.OutDir, "16x16x20-selection.rle"), "Expected the selection to be saved as 16x16x20-selection.rle, got %v", path)
+	again, err := p.SaveSelection(glider, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, again == filepath.Join(p.OutDir, "16x16x20-selection2.rle"), "Expected a second selection not to overwrite the first, got %v", again)
+
+	loaded, err := gol.LoadPattern(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, reflect.DeepEqual(loaded, glider), "Expected the saved selection to load back as %v, got %v", glider, loaded)
+}
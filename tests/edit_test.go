@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// editTurn runs an empty width x height world on the broker for a turn, with the given edits queued before it.
+// Blocks drawn by the edits are still lifes, so the alive cells after the turn are the cells the edits left alive.
+func editTurn(t *testing.T, width, height int, edits ...stubs.EditCellsRequest) []util.Cell {
+	client, err := rpc.Dial("tcp", "127.0.0.1:8030")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	world := make([][]byte, height)
+	for i := range world {
+		world[i] = make([]byte, width)
+	}
+	request := stubs.BreakWorldRequest{Threads: 2, ImageWidth: width, ImageHeight: height, World: world}
+	breakWorld := func(turns int) *stubs.BreakWorldResponse {
+		if err := client.Call(stubs.PreBreakHandler, stubs.PreBreakRequest{}, new(stubs.PreBreakResponse)); err != nil {
+			t.Fatal(err)
+		}
+		request.Turns = turns
+		response := new(stubs.BreakWorldResponse)
+		if err := client.Call(stubs.BreakWorldHandler, request, response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	// The first call starts the run the edits are queued against, and the second applies them before its turn.
+	breakWorld(0)
+	for _, edit := range edits {
+		if err := client.Call(stubs.EditCellsHandler, edit, new(stubs.EditCellsResponse)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return breakWorld(1).AliveCells
+}
+
+// TestEditCells checks that the broker sets, clears and pastes cells, wrapping them around the edges of the world.
+func TestEditCells(t *testing.T) {
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16}
+	corners := []util.Cell{{X: 0, Y: 0}, {X: 15, Y: 0}, {X: 0, Y: 15}, {X: 15, Y: 15}}
+
+	t.Run("set", func(t *testing.T) {
+		set := []util.Cell{{X: -1, Y: -1}, {X: 16, Y: -1}, {X: -1, Y: 16}, {X: 16, Y: 16}}
+		assertEqualBoard(t, editTurn(t, 16, 16, stubs.EditCellsRequest{Set: set}), corners, p)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		set := append([]util.Cell{{X: 8, Y: 8}}, corners...)
+		clear := []util.Cell{{X: 24, Y: -8}}
+		assertEqualBoard(t, editTurn(t, 16, 16, stubs.EditCellsRequest{Set: set, Clear: clear}), corners, p)
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		block := [][]byte{
+			{0, 0, 0, 0},
+			{0, 255, 255, 0},
+			{0, 255, 255, 0},
+			{0, 0, 0, 0},
+		}
+		// The pattern overwrites the cells it covers, so the dead cells around its block clear what was set there.
+		edits := []stubs.EditCellsRequest{
+			{Set: []util.Cell{{X: 14, Y: 14}, {X: 1, Y: 1}}},
+			{Pattern: block, Offset: util.Cell{X: 14, Y: 14}},
+		}
+		assertEqualBoard(t, editTurn(t, 16, 16, edits...), corners, p)
+	})
+}
+
+// TestPaste pastes a pattern file into a paused run, as the v key does with -paste, and checks it is there once resumed.
+func TestPaste(t *testing.T) {
+	p := editParams()
+	p.PasteFile = filepath.Join(t.TempDir(), "block.rle")
+	if err := os.WriteFile(p.PasteFile, []byte("x = 2, y = 2, rule = B3/S23\n2o$2o!\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	events := editWhilePaused(t, p, func() {
+		if err := gol.PastePattern(p.PasteFile, util.Cell{X: 15, Y: 7}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assertEqualBoard(t, finalAlive(events), []util.Cell{{X: 0, Y: 7}, {X: 15, Y: 7}, {X: 0, Y: 8}, {X: 15, Y: 8}}, p)
+}
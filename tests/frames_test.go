@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// forwardFrames sends a glider through a frame exporter, then a cell born on the bottom row each turn for four turns.
+func forwardFrames(t *testing.T, f *gol.FrameExporter) {
+	in := make(chan gol.Event)
+	out := make(chan gol.Event, 100)
+	go f.Forward(in, out)
+	in <- gol.CellsFlipped{CompletedTurns: 0, Cells: []util.Cell{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}}
+	in <- gol.StateChange{CompletedTurns: 0, NewState: gol.Executing}
+	for turn := 1; turn <= 4; turn++ {
+		in <- gol.CellsFlipped{CompletedTurns: turn, Cells: []util.Cell{{X: turn + 3, Y: 5}}}
+		in <- gol.TurnComplete{CompletedTurns: turn}
+	}
+	close(in)
+	for range out {
+	}
+}
+
+// TestFramesPng exports every other turn as a scaled png and checks the frames are numbered in order.
+func TestFramesPng(t *testing.T) {
+	p := gol.Params{ImageWidth: 8, ImageHeight: 8}
+	dir := t.TempDir()
+	f, err := gol.NewFrameExporter(dir, "png", 2, 3, 25, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwardFrames(t, f)
+
+	for frame, born := range []int{0, 2, 4} {
+		file, err := os.Open(filepath.Join(dir, fmt.Sprintf("%06d.png", frame)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert(t, img.Bounds().Dx() == 24 && img.Bounds().Dy() == 24, "Expected a 24x24 frame, got %v", img.Bounds())
+		cells := imageCells(img, 3)
+		assert(t, len(cells) == 5+born, "Expected %v cells to have been born in frame %v, got %v", born, frame, len(cells)-5)
+	}
+	_, err = os.Stat(filepath.Join(dir, "000003.png"))
+	assert(t, os.IsNotExist(err), "Expected only three frames")
+}
+
+// TestFramesY4m exports every turn as a video and checks its header and length.
+func TestFramesY4m(t *testing.T) {
+	p := gol.Params{ImageWidth: 8, ImageHeight: 6}
+	path := filepath.Join(t.TempDir(), "life.y4m")
+	f, err := gol.NewFrameExporter(path, "y4m", 1, 1, 10, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwardFrames(t, f)
+
+	video, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := "YUV4MPEG2 W8 H6 F10:1 Ip A1:1 C420jpeg\n"
+	assert(t, bytes.HasPrefix(video, []byte(header)), "Unexpected header %q", video[:len(header)])
+	frame := len("FRAME\n") + 8*6 + 2*4*3
+	assert(t, len(video) == len(header)+5*frame, "Expected 5 frames of %v bytes, got %v bytes", frame, len(video)-len(header))
+	first := video[len(header)+len("FRAME\n"):]
+	assert(t, first[1] == 235 && first[0] == 16, "Expected alive cells to be bright and dead cells dark")
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"image"
+	"image/gif"
+	"image/png"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// imageCells returns the alive cells of an image drawn with cells of the given scale.
+func imageCells(img image.Image, scale int) []util.Cell {
+	var cells []util.Cell
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += scale {
+		for x := bounds.Min.X; x < bounds.Max.X; x += scale {
+			if r, _, _, _ := img.At(x, y).RGBA(); r != 0 {
+				cells = append(cells, util.Cell{X: x / scale, Y: y / scale})
+			}
+		}
+	}
+	return cells
+}
+
+// TestPng checks that the final board written as a png matches the final alive cells.
+func TestPng(t *testing.T) {
+	p := gol.Params{
+		Turns:        100,
+		Threads:      8,
+		ImageWidth:   64,
+		ImageHeight:  64,
+		OutputFormat: "png",
+	}
+	emptyOutFolder()
+	cells := runFinal(t, p)
+
+	file, err := os.Open("out/64x64x100.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualBoard(t, imageCells(img, 1), cells, p)
+}
+
+// TestGif records every 10th turn of a run into a GIF with a frame limit and checks a frame against a run of that length.
+func TestGif(t *testing.T) {
+	p := gol.Params{
+		Turns:       100,
+		Threads:     8,
+		ImageWidth:  64,
+		ImageHeight: 64,
+		GifEvery:    10,
+		GifFrames:   5,
+		GifScale:    3,
+	}
+	emptyOutFolder()
+	runFinal(t, p)
+
+	file, err := os.Open("out/64x64x100.gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	recording, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, len(recording.Image) == 5, "Expected 5 frames, got %v instead", len(recording.Image))
+	size := recording.Image[0].Bounds().Size()
+	assert(t, size == image.Pt(192, 192), "Expected 192x192 frames, got %v instead", size)
+
+	expected := runFinal(t, gol.Params{Turns: 40, Threads: 8, ImageWidth: 64, ImageHeight: 64})
+	assertEqualBoard(t, imageCells(recording.Image[4], 3), expected, p)
+}
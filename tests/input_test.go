@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestInput loads an image from an arbitrary path, sized from its header, and writes the output under a custom name.
+func TestInput(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("images/16x16.pgm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "board.pgm")
+	if err := os.WriteFile(input, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	width, height, err := gol.ReadSize(input)
+	assert(t, err == nil && width == 16 && height == 16, "Expected a 16x16 board, got %vx%v (%v) instead", width, height, err)
+
+	p := gol.Params{
+		Turns:       100,
+		Threads:     8,
+		ImageWidth:  width,
+		ImageHeight: height,
+		Input:       input,
+		OutDir:      filepath.Join(dir, "results"),
+		OutName:     "{name}-{w}x{h}-{turn}",
+	}
+	cells := runFinal(t, p)
+
+	output := readAliveCells(t, filepath.Join(dir, "results", "board-16x16-100.pgm"), width, height)
+	assertEqualBoard(t, output, cells, p)
+}
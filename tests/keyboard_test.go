@@ -16,6 +16,7 @@ func TestKeyboard(t *testing.T) {
 	t.Run("q", testKeyboardQ)
 	t.Run("p+s", testKeyboardPS)
 	t.Run("p+q", testKeyboardPQ)
+	t.Run("p+seek", testKeyboardPSeek)
 }
 
 func testKeyboardP(t *testing.T) {
@@ -215,3 +216,54 @@ func testKeyboardPQ(t *testing.T) {
 
 	tester.Loop()
 }
+
+func testKeyboardPSeek(t *testing.T) {
+	params := gol.Params{
+		Turns:       100000000,
+		Threads:     8,
+		ImageWidth:  512,
+		ImageHeight: 512,
+		Live:        true,
+	}
+
+	keyPresses := make(chan rune, 10)
+	events := make(chan gol.Event, 1000)
+
+	golDone := make(chan bool, 1)
+
+	go func() {
+		gol.Run(params, events, keyPresses)
+		golDone <- true
+	}()
+
+	tester := MakeTester(t, params, keyPresses, events, golDone)
+
+	go func() {
+		tester.TestStartsExecuting()
+
+		// Turn 10 can only be sought once it has been run.
+		tester.TestReachesTurn(10)
+
+		keyPresses <- 'p'
+		tester.TestPauses()
+
+		keyPresses <- '1'
+		keyPresses <- '0'
+		keyPresses <- 'g'
+		turn := tester.TestPauses()
+		assert(t, turn == 10, "Seeking to turn 10 should pause at turn 10, not %v", turn)
+
+		keyPresses <- ','
+		turn = tester.TestPauses()
+		assert(t, turn == 9, "Seeking back one turn should pause at turn 9, not %v", turn)
+
+		keyPresses <- 's'
+		tester.TestOutput()
+
+		keyPresses <- 'p'
+		keyPresses <- 'q'
+		tester.Stop(false)
+	}()
+
+	tester.Loop()
+}
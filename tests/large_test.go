@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// runBoard runs gol for the given Params until it has finished, saving the final board to the output directory.
+func runBoard(p gol.Params) {
+	events := make(chan gol.Event, 1000)
+	go gol.Run(p, events, nil)
+	for range events {
+	}
+}
+
+// BenchmarkLargeBoard loads and saves a 5120x5120 board, which should take seconds rather than minutes.
+// The pgm benchmark goes through the io goroutine both ways and the broker for no turns,
+// and the snapshot benchmark packs the board into a snapshot and reads it back.
+func BenchmarkLargeBoard(b *testing.B) {
+	p := gol.Params{
+		Threads:     8,
+		ImageWidth:  5120,
+		ImageHeight: 5120,
+		Soup:        true,
+		Seed:        1,
+		Density:     0.1,
+		OutDir:      b.TempDir(),
+	}
+	runBoard(p)
+	input := filepath.Join(p.OutDir, "5120x5120x0.pgm")
+	world, err := gol.LoadPattern(input)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("pgm", func(b *testing.B) {
+		p := p
+		p.Soup = false
+		p.Input = input
+		p.OutDir = b.TempDir()
+		b.SetBytes(int64(p.ImageWidth * p.ImageHeight))
+		for i := 0; i < b.N; i++ {
+			runBoard(p)
+		}
+	})
+
+	b.Run("snapshot", func(b *testing.B) {
+		var buffer bytes.Buffer
+		b.SetBytes(int64(p.ImageWidth * p.ImageHeight))
+		for i := 0; i < b.N; i++ {
+			buffer.Reset()
+			if err := util.WriteSnapshot(&buffer, util.NewSnapshot(world, 0)); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := util.ReadSnapshot(&buffer); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
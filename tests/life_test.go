@@ -0,0 +1,29 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestLifeFormats writes the 64x64 image as .cells, Life 1.06 and Life 1.05 and checks that each loads back into the same board.
+func TestLifeFormats(t *testing.T) {
+	for _, format := range []string{"cells", "lif", "life"} {
+		t.Run(format, func(t *testing.T) {
+			p := gol.Params{
+				Turns:        0,
+				Threads:      8,
+				ImageWidth:   64,
+				ImageHeight:  64,
+				OutputFormat: format,
+			}
+			emptyOutFolder()
+			cells := runFinal(t, p)
+
+			p.Pattern = fmt.Sprintf("out/64x64x0.%v", format)
+			roundTrip := runFinal(t, p)
+			assertEqualBoard(t, roundTrip, cells, p)
+		})
+	}
+}
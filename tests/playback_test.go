@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// recordLive records a live run with the given Params to an event log, returning its path and final alive cells.
+func recordLive(t *testing.T, p gol.Params) (string, []util.Cell) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	recorder, err := gol.NewRecorder(path, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := make(chan gol.Event)
+	out := make(chan gol.Event, 1000)
+	go recorder.Forward(in, out)
+	go gol.Run(p, in, nil)
+	var final []util.Cell
+	for event := range out {
+		if e, ok := event.(gol.FinalTurnComplete); ok {
+			final = e.Alive
+		}
+	}
+	return path, final
+}
+
+// TestPlayback loads a recorded live run and checks that stepping through its frames,
+// forwards and back, agrees with seeking to them, and that the last frame is the final board.
+func TestPlayback(t *testing.T) {
+	p := gol.Params{
+		Turns:       200,
+		Threads:     8,
+		ImageWidth:  16,
+		ImageHeight: 16,
+		Soup:        true,
+		Seed:        3,
+		Density:     0.4,
+		Live:        true,
+	}
+	emptyOutFolder()
+	path, final := recordLive(t, p)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, strings.Contains(string(data), `"type":"Keyframe"`), "Expected the event log to have keyframes")
+
+	l, err := gol.LoadEventLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	last := l.Frames() - 1
+	assert(t, l.Turn(0) == 0 && l.Turn(last) == 200, "Expected frames from turn 0 to 200, got %v to %v", l.Turn(0), l.Turn(last))
+	assert(t, l.Turn(l.FrameAt(120)) == 120, "Expected to find the frame of turn 120, got turn %v", l.Turn(l.FrameAt(120)))
+
+	world := l.World(0)
+	for frame := 1; frame <= last; frame++ {
+		for _, cell := range l.Flips(frame) {
+			world[cell.Y][cell.X] ^= 0xFF
+		}
+		if !reflect.DeepEqual(world, l.World(frame)) {
+			t.Fatalf("Stepping forward to frame %v does not match seeking to it", frame)
+		}
+	}
+	assertEqualBoard(t, aliveCells(world), final, p)
+	for frame := last; frame > 0; frame-- {
+		for _, cell := range l.Flips(frame) {
+			world[cell.Y][cell.X] ^= 0xFF
+		}
+	}
+	assert(t, reflect.DeepEqual(world, l.World(0)), "Stepping back to the first frame does not match seeking to it")
+
+	// Without its keyframes, every frame is built up from the start of the log instead.
+	var lines []string
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if !strings.Contains(line, `"type":"Keyframe"`) {
+			lines = append(lines, line)
+		}
+	}
+	bare := filepath.Join(t.TempDir(), "bare.jsonl")
+	if err := os.WriteFile(bare, []byte(strings.Join(lines, "")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := gol.LoadEventLog(bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	assert(t, b.Frames() == l.Frames(), "Expected the log without keyframes to have %v frames, got %v", l.Frames(), b.Frames())
+	for _, frame := range []int{0, last / 2, last} {
+		assert(t, reflect.DeepEqual(b.World(frame), l.World(frame)), "Expected frame %v to be the same without keyframes", frame)
+	}
+}
+
+func aliveCells(world [][]byte) []util.Cell {
+	var cells []util.Cell
+	for y := range world {
+		for x := range world[y] {
+			if world[y][x] == 0xFF {
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return cells
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// encodePnm writes the 16x16 board with the given alive cells in one of the Netpbm formats.
+// Dead and alive pixels use the given sample values, and a comment is put in every gap of the header.
+func encodePnm(magic string, alive []util.Cell, maxval, dead, live int) []byte {
+	const size = 16
+	isAlive := make(map[util.Cell]bool)
+	for _, cell := range alive {
+		isAlive[cell] = true
+	}
+	sample := func(x, y int) int {
+		if isAlive[util.Cell{X: x, Y: y}] {
+			return live
+		}
+		return dead
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%v # magic\n%v # width\n%v\n", magic, size, size)
+	if magic != "P1" && magic != "P4" {
+		fmt.Fprintf(&buf, "# maxval follows\n%v\n", maxval)
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			v := sample(x, y)
+			switch magic {
+			case "P1":
+				// Plain bitmaps need no whitespace between bits.
+				fmt.Fprint(&buf, v)
+			case "P2":
+				fmt.Fprintf(&buf, "%v ", v)
+			case "P3":
+				fmt.Fprintf(&buf, "%v %v %v ", v, v, v)
+			case "P4":
+				if x%8 == 0 {
+					b := 0
+					for i := 0; i < 8; i++ {
+						b = b<<1 | sample(x+i, y)
+					}
+					buf.WriteByte(byte(b))
+				}
+			case "P5", "P6":
+				for i := 0; i < map[string]int{"P5": 1, "P6": 3}[magic]; i++ {
+					if maxval > 255 {
+						buf.WriteByte(byte(v >> 8))
+					}
+					buf.WriteByte(byte(v))
+				}
+			}
+		}
+		if magic == "P1" || magic == "P2" || magic == "P3" {
+			buf.WriteString("\n# row\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+// TestPnm loads the 16x16 image from each of the Netpbm formats and checks that the same board is read every time.
+func TestPnm(t *testing.T) {
+	p := gol.Params{Turns: 0, Threads: 8, ImageWidth: 16, ImageHeight: 16}
+	alive := readAliveCells(t, "images/16x16.pgm", 16, 16)
+
+	images := []struct {
+		name                     string
+		magic                    string
+		maxval, dead, aliveValue int
+	}{
+		{"plain.pbm", "P1", 1, 0, 1},
+		{"plain.pgm", "P2", 15, 3, 12},
+		{"plain.ppm", "P3", 65535, 1000, 60000},
+		{"raw.pbm", "P4", 1, 0, 1},
+		// Samples that look like whitespace must not be skipped.
+		{"raw.pgm", "P5", 255, ' ', 200},
+		{"wide.pgm", "P5", 1000, '\n', 999},
+		{"raw.ppm", "P6", 255, '\t', 255},
+	}
+	dir := t.TempDir()
+	for _, image := range images {
+		t.Run(image.name, func(t *testing.T) {
+			path := filepath.Join(dir, image.name)
+			data := encodePnm(image.magic, alive, image.maxval, image.dead, image.aliveValue)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			p.Input = path
+			assertEqualBoard(t, runFinal(t, p), alive, p)
+		})
+	}
+
+	for _, format := range []string{"pbm", "ppm"} {
+		t.Run(format, func(t *testing.T) {
+			p.Input = ""
+			p.OutputFormat = format
+			p.OutDir = dir
+			runFinal(t, p)
+
+			p.Input = filepath.Join(dir, "16x16x0."+format)
+			p.OutputFormat = ""
+			assertEqualBoard(t, runFinal(t, p), alive, p)
+		})
+	}
+}
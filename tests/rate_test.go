@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestTurnRate runs a limited run, speeds it up with the + key and checks that it keeps to each limit in turn.
+// Only the limits are checked, as a loaded machine can run slower than the limit but never faster.
+func TestTurnRate(t *testing.T) {
+	p := gol.Params{
+		Turns:       1000000,
+		Threads:     8,
+		ImageWidth:  16,
+		ImageHeight: 16,
+		TurnRate:    10,
+	}
+	emptyOutFolder()
+	events := make(chan gol.Event, 1000)
+	keyPresses := make(chan rune, 10)
+	start := time.Now()
+	go gol.Run(p, events, keyPresses)
+
+	var rates []gol.TurnRateChanged
+	var changed, quit time.Time
+	final := 0
+	go func() {
+		time.Sleep(time.Second)
+		keyPresses <- '+'
+		time.Sleep(time.Second)
+		keyPresses <- 'q'
+	}()
+	for event := range events {
+		switch e := event.(type) {
+		case gol.TurnRateChanged:
+			rates = append(rates, e)
+			changed = time.Now()
+		case gol.StateChange:
+			if e.NewState == gol.Quitting {
+				final, quit = e.CompletedTurns, time.Now()
+			}
+		}
+	}
+
+	if len(rates) != 2 {
+		t.Fatalf("Expected the turn rate to be reported twice, got %v", rates)
+	}
+	assert(t, rates[0] == gol.TurnRateChanged{CompletedTurns: 0, TurnRate: 10}, "Expected the run to start limited to 10 turns/sec, got %v", rates[0])
+	assert(t, rates[1].TurnRate == 20, "Expected + to raise the limit to 20 turns/sec, got %v", rates[1].TurnRate)
+
+	// The events arrive a little after the turns they report, so a couple of turns are allowed over each limit.
+	assertRate := func(turns, rate int, elapsed time.Duration) {
+		limit := int(float64(rate)*elapsed.Seconds()) + 2
+		assert(t, turns >= 1 && turns <= limit,
+			"Expected between 1 and %v turns in %v at %v turns/sec, got %v", limit, elapsed, rate, turns)
+	}
+	assertRate(rates[1].CompletedTurns, 10, changed.Sub(start))
+	assertRate(final-rates[1].CompletedTurns, 20, quit.Sub(changed))
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestRecord records a 16x16 run to an event log and checks that replaying it gives back the same events.
+func TestRecord(t *testing.T) {
+	p := gol.Params{
+		Turns:       10,
+		Threads:     8,
+		ImageWidth:  16,
+		ImageHeight: 16,
+		Statistics:  true,
+	}
+	path := filepath.Join(t.TempDir(), "16x16x10.jsonl")
+	recorder, err := gol.NewRecorder(path, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan gol.Event)
+	out := make(chan gol.Event)
+	go recorder.Forward(in, out)
+	go gol.Run(p, in, nil)
+	var recorded []gol.Event
+	for event := range out {
+		recorded = append(recorded, event)
+	}
+
+	replayedParams, events, err := gol.Replay(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, reflect.DeepEqual(replayedParams, p), "Replayed params %v do not match recorded params %v", replayedParams, p)
+	var replayed []gol.Event
+	for event := range events {
+		replayed = append(replayed, event)
+	}
+
+	assert(t, len(replayed) == len(recorded), "Replayed %v events, recorded %v", len(replayed), len(recorded))
+	for i := 0; i < len(replayed) && i < len(recorded); i++ {
+		assert(t, reflect.DeepEqual(replayed[i], recorded[i]), "Replayed event %#v does not match recorded event %#v", replayed[i], recorded[i])
+	}
+}
+
+// TestEventTypes records an event of every type with fields set, and checks that each one replays the same.
+func TestEventTypes(t *testing.T) {
+	samples := map[string]gol.Event{
+		"AliveCellsCount":     gol.AliveCellsCount{CompletedTurns: 1, CellsCount: 2},
+		"ImageOutputComplete": gol.ImageOutputComplete{CompletedTurns: 1, Filename: "16x16x1"},
+		"StateChange":         gol.StateChange{CompletedTurns: 1, NewState: gol.Paused},
+		"CellFlipped":         gol.CellFlipped{CompletedTurns: 1, Cell: util.Cell{X: 3, Y: 4}},
+		"CellsFlipped":        gol.CellsFlipped{CompletedTurns: 1, Cells: []util.Cell{{X: 1, Y: 2}, {X: 5, Y: 6}}},
+		"TurnComplete":        gol.TurnComplete{CompletedTurns: 1},
+		"FinalTurnComplete":   gol.FinalTurnComplete{CompletedTurns: 1, Alive: []util.Cell{{X: 7, Y: 8}}},
+		"TurnStatistics": gol.TurnStatistics{
+			CompletedTurns: 1, Births: 2, Deaths: 3, Edited: 4, Skipped: 5, Population: 6,
+			TopLeft: util.Cell{X: 1, Y: 2}, BottomRight: util.Cell{X: 3, Y: 4},
+		},
+		"StabilityDetected": gol.StabilityDetected{CompletedTurns: 1, Period: 2},
+		"TurnRateChanged":   gol.TurnRateChanged{CompletedTurns: 1, TurnRate: 20},
+		"EngineDetails":     gol.EngineDetails{CompletedTurns: 1, Broker: "127.0.0.1:8030", Workers: []string{"127.0.0.1:8040"}},
+	}
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16}
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	recorder, err := gol.NewRecorder(path, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := gol.EventTypes()
+	for _, name := range types {
+		sample, ok := samples[name]
+		if !ok {
+			t.Fatalf("No sample of the event type %v", name)
+		}
+		if err := recorder.Write(sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, len(types) == len(samples), "Expected %v event types, got %v", len(samples), types)
+
+	_, events, err := gol.Replay(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 0
+	for event := range events {
+		expected := samples[types[i]]
+		assert(t, reflect.DeepEqual(event, expected), "Expected %#v to replay the same, got %#v", expected, event)
+		i++
+	}
+	assert(t, i == len(types), "Expected %v events to be replayed, got %v", len(types), i)
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestResume runs 50 turns, resumes from the saved image and from a saved snapshot,
+// and checks that both carry on to the same board as a run of 100 turns straight through.
+func TestResume(t *testing.T) {
+	p := gol.Params{Turns: 100, Threads: 8, ImageWidth: 64, ImageHeight: 64}
+	emptyOutFolder()
+	expected := runFinal(t, p)
+
+	for _, format := range []string{"pgm", "snap"} {
+		t.Run(format, func(t *testing.T) {
+			first := p
+			first.Turns = 50
+			first.OutputFormat = format
+			runFinal(t, first)
+
+			path := "out/64x64x50." + format
+			turn, err := gol.ReadTurn(path)
+			assert(t, err == nil && turn == 50, "Expected to resume from turn 50, got %v (%v) instead", turn, err)
+
+			resumed := p
+			resumed.Input = path
+			resumed.StartTurn = turn
+			events := make(chan gol.Event)
+			go gol.Run(resumed, events, nil)
+			for event := range events {
+				switch e := event.(type) {
+				case gol.StateChange:
+					assert(t, e.CompletedTurns >= 50, "Expected every event of the resumed run to be after turn 50, got %v", e.CompletedTurns)
+				case gol.FinalTurnComplete:
+					assert(t, e.CompletedTurns == 100, "Expected the resumed run to end at turn 100, got %v", e.CompletedTurns)
+					assertEqualBoard(t, e.Alive, expected, p)
+				}
+			}
+			_, err = os.Stat("out/64x64x100.pgm")
+			assert(t, err == nil, "Expected the output of the resumed run to be named after turn 100")
+		})
+	}
+
+	// Only the default name of a saved image gives its turn, and only when it matches the size of the image.
+	t.Run("names", func(t *testing.T) {
+		data, err := os.ReadFile("out/64x64x50.pgm")
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		for _, name := range []string{"run-final.pgm", "32x32x50.pgm", "64x64.pgm", "0x64x64x50.pgm"} {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			turn, err := gol.ReadTurn(path)
+			assert(t, err != nil, "Expected no turn to be read from the name %v, got %v", name, turn)
+		}
+		_, err = gol.ReadTurn("images/512x512.pgm")
+		assert(t, err != nil, "Expected no turn to be read from the name of images/512x512.pgm")
+	})
+}
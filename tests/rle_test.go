@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+const gosperGliderGun = `#N Gosper glider gun
+#C The first known gun and the first known finite pattern with unbounded growth.
+x = 36, y = 9, rule = B3/S23
+24bo$22bobo$12b2o6b2o12b2o$11bo3bo4b2o12b2o$2o8bo5bo3b2o$2o8bo3bob2o4b
+obo$10bo5bo7bo$11bo3bo$12b2o!
+`
+
+func runFinal(t *testing.T, p gol.Params) []util.Cell {
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	var cells []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.FinalTurnComplete:
+			cells = e.Alive
+		}
+	}
+	return cells
+}
+
+// TestRle loads the Gosper glider gun from an rle file, writes it back out as rle and checks that the board round-trips.
+func TestRle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gosper.rle")
+	if err := os.WriteFile(path, []byte(gosperGliderGun), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := gol.Params{
+		Turns:        0,
+		Threads:      8,
+		ImageWidth:   64,
+		ImageHeight:  64,
+		Pattern:      path,
+		PatternAt:    util.Cell{X: 1, Y: 2},
+		PlacePattern: true,
+		OutputFormat: "rle",
+	}
+	emptyOutFolder()
+	cells := runFinal(t, p)
+	assert(t, len(cells) == 36, "Expected 36 alive cells in the glider gun, got %v instead", len(cells))
+	placed := false
+	for _, cell := range cells {
+		placed = placed || cell == util.Cell{X: 1, Y: 6}
+	}
+	assert(t, placed, "Expected the glider gun to be placed with its top left corner at (1, 2)")
+
+	// The output covers the whole board, so centring it puts every cell back where it was.
+	p.Pattern = "out/64x64x0.rle"
+	p.PlacePattern = false
+	roundTrip := runFinal(t, p)
+	p.Pattern = path
+	assertEqualBoard(t, roundTrip, cells, p)
+}
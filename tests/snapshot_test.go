@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"net/rpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestSnapshot saves the final board as a snapshot, checks its metadata and loads it back as the input of another run.
+func TestSnapshot(t *testing.T) {
+	p := gol.Params{
+		Turns:        100,
+		Threads:      8,
+		ImageWidth:   64,
+		ImageHeight:  64,
+		OutputFormat: "snap",
+	}
+	emptyOutFolder()
+	cells := runFinal(t, p)
+
+	path := "out/64x64x100.snap"
+	s, err := util.LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, s.Width == 64 && s.Height == 64, "Expected a 64x64 snapshot, got %vx%v instead", s.Width, s.Height)
+	assert(t, s.Turn == 100, "Expected the snapshot to be of turn 100, got %v instead", s.Turn)
+	assert(t, s.Rule == "B3/S23" && s.Topology == "torus", "Expected B3/S23 on a torus, got %v on a %v instead", s.Rule, s.Topology)
+
+	p.Turns = 0
+	p.Input = path
+	p.OutputFormat = ""
+	assertEqualBoard(t, runFinal(t, p), cells, p)
+
+	// Any change to the body must be caught by the checksum or by gzip itself.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-20] ^= 0xff
+	corrupt := t.TempDir() + "/corrupt.snap"
+	if err := os.WriteFile(corrupt, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = util.LoadSnapshot(corrupt)
+	assert(t, err != nil, "Expected an error loading a corrupt snapshot")
+}
+
+// TestBrokerSnapshot starts a broker of its own that loads a snapshot, checks that it holds the world of the snapshot,
+// and closes it so that it saves the world to another snapshot.
+func TestBrokerSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	broker := filepath.Join(dir, "broker")
+	if output, err := exec.Command("go", "build", "-o", broker, "uk.ac.bris.cs/gameoflife/broker").CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+	world, err := gol.LoadPattern("images/64x64.pgm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	load, save := filepath.Join(dir, "load.snap"), filepath.Join(dir, "save.snap")
+	if err := util.SaveSnapshot(load, util.NewSnapshot(world, 42)); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(broker, "-port", "8031", "-load", load, "-save", save)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	var client *rpc.Client
+	for i := 0; i < 50 && client == nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+		client, _ = rpc.Dial("tcp", "127.0.0.1:8031")
+	}
+	if client == nil {
+		t.Fatal("Could not connect to the broker")
+	}
+	defer client.Close()
+
+	state := new(stubs.CurrentStateResponse)
+	if err := client.Call(stubs.CurrentStateHandler, stubs.CurrentStateRequest{}, state); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, state.CompletedTurns == 42, "Expected the broker to be at turn 42, got %v", state.CompletedTurns)
+	assert(t, reflect.DeepEqual(state.World, world), "Expected the broker to hold the world of the snapshot")
+
+	if err := client.Call(stubs.BrokerCloseHandler, stubs.CloseRequest{}, new(stubs.CloseResponse)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := util.LoadSnapshot(save)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, s.Turn == 42 && reflect.DeepEqual(s.World, world), "Expected the broker to save the world it loaded")
+}
+
+// TestSnapshotSize checks that snapshots and images claiming to be impossibly large are rejected before they are read.
+func TestSnapshotSize(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"huge.snap": "GOLSNAP 1\nwidth 1000000\nheight 1000000\nturn 0\ncrc32 00000000\n\n",
+		"huge.pgm":  "P5 100000 100000 255\n",
+		"huge.rle":  "x = 100000, y = 100000, rule = B3/S23\no!\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := gol.LoadPattern(path)
+		assert(t, err != nil && strings.Contains(err.Error(), "invalid size"), "Expected %v to be rejected for its size, got %v", name, err)
+	}
+}
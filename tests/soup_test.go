@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestSoup generates soups from a seed and checks that they can be made again exactly,
+// that they have the requested symmetry and that the seed is recorded in the output.
+func TestSoup(t *testing.T) {
+	p := gol.Params{
+		Turns:        0,
+		Threads:      8,
+		ImageWidth:   64,
+		ImageHeight:  64,
+		Soup:         true,
+		Seed:         42,
+		Density:      0.3,
+		OutputFormat: "snap",
+	}
+	emptyOutFolder()
+	soup := runFinal(t, p)
+	assert(t, len(soup) > 64*64/5 && len(soup) < 64*64*2/5, "Expected about 30%% of the soup to be alive, got %v cells", len(soup))
+	assertEqualBoard(t, runFinal(t, p), soup, p)
+
+	s, err := util.LoadSnapshot("out/64x64x0.snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, strings.Contains(s.Source, "seed=42"), "Expected the seed in the snapshot, got %q instead", s.Source)
+
+	// A run resumed from the snapshot records the soup it came from in its own snapshot.
+	resumed := p
+	resumed.Soup, resumed.Input, resumed.Turns = false, "out/64x64x0.snap", 10
+	runFinal(t, resumed)
+	r, err := util.LoadSnapshot("out/64x64x10.snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, r.Source == s.Source, "Expected the resumed snapshot to keep the source %q, got %q instead", s.Source, r.Source)
+
+	p.Seed = 43
+	other := runFinal(t, p)
+	assert(t, !checkEqualBoard(other, soup), "Expected a different seed to give a different soup")
+
+	for _, symmetry := range []string{"C2", "C4", "D8"} {
+		p.Symmetry = symmetry
+		alive := make(map[util.Cell]bool)
+		for _, cell := range runFinal(t, p) {
+			alive[cell] = true
+		}
+		for cell := range alive {
+			images := []util.Cell{{X: 63 - cell.X, Y: 63 - cell.Y}}
+			if symmetry != "C2" {
+				images = append(images, util.Cell{X: 63 - cell.Y, Y: cell.X})
+			}
+			if symmetry == "D8" {
+				images = append(images, util.Cell{X: 63 - cell.X, Y: cell.Y}, util.Cell{X: cell.Y, Y: cell.X})
+			}
+			for _, image := range images {
+				if !alive[image] {
+					t.Fatalf("%v soup: %v is alive but %v is not", symmetry, cell, image)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestStability checks that the 16x16 glider is detected as periodic and fast-forwarded to the correct final turn.
+func TestStability(t *testing.T) {
+	p := gol.Params{
+		Turns:       100000000,
+		Threads:     8,
+		ImageWidth:  16,
+		ImageHeight: 16,
+		FastForward: true,
+	}
+	// The glider returns to its starting position every 64 turns on a 16x16 torus.
+	expectedAlive := readAliveCells(t, "images/16x16.pgm", p.ImageWidth, p.ImageHeight)
+
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	var cells []util.Cell
+	period := 0
+	for event := range events {
+		switch e := event.(type) {
+		case gol.StabilityDetected:
+			period = e.Period
+		case gol.FinalTurnComplete:
+			assert(t, e.CompletedTurns == p.Turns, "FinalTurnComplete should have a CompletedTurns of %v, not %v", p.Turns, e.CompletedTurns)
+			cells = e.Alive
+		}
+	}
+	assert(t, period == 64, "Expected a StabilityDetected event with a period of 64, got %v instead", period)
+	assertEqualBoard(t, cells, expectedAlive, p)
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestStatistics checks that a TurnStatistics event is sent for every turn of a 64x64 run
+// and that births and deaths account for every change in the population.
+func TestStatistics(t *testing.T) {
+	p := gol.Params{
+		Turns:       100,
+		Threads:     8,
+		ImageWidth:  64,
+		ImageHeight: 64,
+		Statistics:  true,
+	}
+	population := len(readAliveCells(t, "images/64x64.pgm", p.ImageWidth, p.ImageHeight))
+
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	turn := 0
+	var last gol.TurnStatistics
+	var alive []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.TurnStatistics:
+			turn++
+			assert(t, e.CompletedTurns == turn, "Expected TurnStatistics for turn %v, got turn %v instead", turn, e.CompletedTurns)
+			assertPopulation(t, e, population)
+			population = e.Population
+			last = e
+		case gol.FinalTurnComplete:
+			alive = e.Alive
+		}
+	}
+	assert(t, turn == p.Turns, "Expected %v TurnStatistics events, got %v instead", p.Turns, turn)
+	assert(t, last.Population == len(alive), "Final population %v does not match %v alive cells", last.Population, len(alive))
+
+	topLeft := util.Cell{X: p.ImageWidth, Y: p.ImageHeight}
+	bottomRight := util.Cell{X: -1, Y: -1}
+	for _, cell := range alive {
+		if cell.X < topLeft.X {
+			topLeft.X = cell.X
+		}
+		if cell.Y < topLeft.Y {
+			topLeft.Y = cell.Y
+		}
+		if cell.X > bottomRight.X {
+			bottomRight.X = cell.X
+		}
+		if cell.Y > bottomRight.Y {
+			bottomRight.Y = cell.Y
+		}
+	}
+	assert(
+		t,
+		last.TopLeft == topLeft && last.BottomRight == bottomRight,
+		"Expected bounding box %v-%v, got %v-%v instead",
+		topLeft,
+		bottomRight,
+		last.TopLeft,
+		last.BottomRight,
+	)
+}
+
+func assertPopulation(t *testing.T, e gol.TurnStatistics, population int) {
+	assert(
+		t,
+		e.Population == population+e.Edited+e.Births-e.Deaths,
+		"At turn %v population %v does not match %v %+v edited + %v births - %v deaths",
+		e.CompletedTurns,
+		e.Population,
+		population,
+		e.Edited,
+		e.Births,
+		e.Deaths,
+	)
+}
+
+// TestStatisticsSkip checks that turns skipped by fast-forwarding are marked by a single row of statistics.
+func TestStatisticsSkip(t *testing.T) {
+	p := gol.Params{
+		Turns:       10000,
+		Threads:     8,
+		ImageWidth:  16,
+		ImageHeight: 16,
+		FastForward: true,
+		Statistics:  true,
+	}
+	population := len(readAliveCells(t, "images/16x16.pgm", p.ImageWidth, p.ImageHeight))
+
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	turn, skips := 0, 0
+	for event := range events {
+		if e, ok := event.(gol.TurnStatistics); ok {
+			step := 1
+			if e.Skipped > 0 {
+				skips++
+				step = e.Skipped
+				assert(t, e.Births == 0 && e.Deaths == 0, "Expected no births or deaths in the skip to turn %v", e.CompletedTurns)
+			}
+			assert(t, e.CompletedTurns == turn+step, "Expected TurnStatistics for turn %v, got turn %v instead", turn+step, e.CompletedTurns)
+			assertPopulation(t, e, population)
+			turn, population = e.CompletedTurns, e.Population
+		}
+	}
+	assert(t, skips == 1, "Expected one skip, got %v", skips)
+	assert(t, turn == p.Turns, "Expected statistics up to turn %v, got %v", p.Turns, turn)
+}
+
+// TestStatisticsEdits checks that cells edited while paused are counted in the statistics of the next turn.
+func TestStatisticsEdits(t *testing.T) {
+	p := editParams()
+	p.Statistics = true
+	block := []util.Cell{{X: 3, Y: 3}, {X: 4, Y: 3}, {X: 3, Y: 4}, {X: 4, Y: 4}}
+	events := editWhilePaused(t, p, func() {
+		if err := gol.EditCells(block, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	population, edited := 0, 0
+	for _, event := range events {
+		if e, ok := event.(gol.TurnStatistics); ok {
+			assertPopulation(t, e, population)
+			population = e.Population
+			edited += e.Edited
+		}
+	}
+	assert(t, edited == len(block), "Expected %v edited cells, got %v", len(block), edited)
+}
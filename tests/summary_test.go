@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/sdl"
+)
+
+// TestSummary runs headless with a summary file and checks the summary against the run.
+func TestSummary(t *testing.T) {
+	p := gol.Params{
+		Turns:       100,
+		Threads:     2,
+		ImageWidth:  64,
+		ImageHeight: 64,
+		SummaryFile: filepath.Join(t.TempDir(), "summary.json"),
+	}
+	emptyOutFolder()
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	sdl.RunHeadless(p, events)
+
+	data, err := os.ReadFile(p.SummaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary struct {
+		Params gol.Params
+		Engine struct {
+			Workers         int
+			WorkerAddresses []string
+		}
+		WallSeconds        float64
+		CompletedTurns     int
+		AverageTurnsPerSec float64
+		PeakTurnsPerSec    int
+		FinalPopulation    int
+		Outputs            []string
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, summary.Params.Turns == 100 && summary.Params.ImageWidth == 64, "Expected the params of the run, got %+v", summary.Params)
+	assert(t, summary.Engine.Workers == 2 && len(summary.Engine.WorkerAddresses) == 2,
+		"Expected 2 workers, got %v at %v", summary.Engine.Workers, summary.Engine.WorkerAddresses)
+	assert(t, summary.CompletedTurns == 100, "Expected 100 completed turns, got %v", summary.CompletedTurns)
+	assert(t, summary.WallSeconds > 0 && summary.AverageTurnsPerSec > 0, "Expected the run to be timed")
+	// The rate is only sampled every 2 seconds, so a shorter run has no peak rather than one made up from the average.
+	if summary.WallSeconds < 2 {
+		assert(t, summary.PeakTurnsPerSec == 0, "Expected no peak rate for a run too short to be sampled, got %v", summary.PeakTurnsPerSec)
+	}
+	population := len(readAliveCells(t, "out/64x64x100.pgm", 64, 64))
+	assert(t, summary.FinalPopulation == population, "Expected a final population of %v, got %v", population, summary.FinalPopulation)
+	assert(t, len(summary.Outputs) == 1 && summary.Outputs[0] == filepath.Join("out", "64x64x100.pgm"),
+		"Expected the final image as the only output, got %v", summary.Outputs)
+}
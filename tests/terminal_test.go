@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestLive runs a glider with live events and checks that flipping the cells of every generation
+// follows it turn by turn to the final board.
+func TestLive(t *testing.T) {
+	p := gol.Params{
+		Turns:       40,
+		Threads:     8,
+		ImageWidth:  16,
+		ImageHeight: 16,
+		Soup:        true,
+		Seed:        7,
+		Density:     0.4,
+		Live:        true,
+	}
+	emptyOutFolder()
+	events := make(chan gol.Event, 1000)
+	go gol.Run(p, events, nil)
+
+	world := make([][]byte, p.ImageHeight)
+	for i := range world {
+		world[i] = make([]byte, p.ImageWidth)
+	}
+	turn := 0
+	var final []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellsFlipped:
+			for _, cell := range e.Cells {
+				world[cell.Y][cell.X] = ^world[cell.Y][cell.X]
+			}
+		case gol.TurnComplete:
+			assert(t, e.CompletedTurns == turn+1, "Expected TurnComplete for turn %v, got %v instead", turn+1, e.CompletedTurns)
+			turn = e.CompletedTurns
+		case gol.FinalTurnComplete:
+			final = e.Alive
+		}
+	}
+	assert(t, turn == p.Turns, "Expected the last TurnComplete to be for turn %v, got %v instead", p.Turns, turn)
+
+	var alive []util.Cell
+	for y, row := range world {
+		for x, cell := range row {
+			if cell == 0xFF {
+				alive = append(alive, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	assertEqualBoard(t, alive, final, p)
+}
+
+// TestTerminalBlocks checks that half blocks and braille pack the cells of a glider into characters.
+func TestTerminalBlocks(t *testing.T) {
+	world := make([][]byte, 4)
+	for i := range world {
+		world[i] = make([]byte, 4)
+	}
+	for _, cell := range []util.Cell{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}} {
+		world[cell.Y][cell.X] = 0xFF
+	}
+
+	halfBlocks := util.HalfBlocks(world, 0, 0, 4, 2)
+	assert(t, reflect.DeepEqual(halfBlocks, []string{" ▀▄ ", "▀▀▀ "}), "Unexpected half blocks %q", halfBlocks)
+
+	braille := util.Braille(world, 0, 0, 2, 1)
+	assert(t, reflect.DeepEqual(braille, []string{"⠬⠆"}), "Unexpected braille %q", braille)
+
+	// Cells beyond the world are drawn dead.
+	outside := util.HalfBlocks(world, -1, 2, 3, 2)
+	assert(t, reflect.DeepEqual(outside, []string{" ▀▀", "   "}), "Unexpected half blocks %q", outside)
+}
@@ -123,6 +123,7 @@ type Tester struct {
 	keyPresses   chan<- rune
 	events       <-chan gol.Event
 	eventWatcher chan gol.Event
+	turnWatcher  chan int
 	quitting     chan bool
 	golDone      <-chan bool
 	turn         int
@@ -154,6 +155,7 @@ func MakeTester(
 		keyPresses:   keyPresses,
 		events:       events,
 		eventWatcher: eventWatcher,
+		turnWatcher:  make(chan int, 1000),
 		quitting:     make(chan bool),
 		golDone:      golDone,
 		turn:         0,
@@ -256,6 +258,11 @@ func (tester *Tester) Loop() {
 					)
 				}
 				tester.turn++
+				// Turns are only watched for by TestReachesTurn, so they are dropped rather than waited on when nothing is.
+				select {
+				case tester.turnWatcher <- e.CompletedTurns:
+				default:
+				}
 				refresh()
 				if tester.sdlSync != nil {
 					tester.sdlSync <- true
@@ -479,6 +486,21 @@ func (tester *Tester) TestPauses() int {
 	}
 }
 
+// TestReachesTurn waits for a TurnComplete of at least turn, which is only sent for every turn of a Live run.
+func (tester *Tester) TestReachesTurn(turn int) {
+	tester.t.Logf("Testing for TurnComplete event of turn %v", turn)
+	timeout(tester.t, 5*time.Second, func() {
+		for completed := range tester.turnWatcher {
+			if completed >= turn {
+				return
+			}
+		}
+	},
+		"No TurnComplete event of turn %v received in 5 seconds",
+		turn,
+	)
+}
+
 func (tester *Tester) TestFinishes(allowedTime int) {
 	tester.t.Logf("Testing for FinalTurnComplete event")
 	timeout(tester.t, time.Duration(allowedTime)*time.Second, func() {
@@ -687,3 +709,59 @@ func (l *LimitedAssert) LimitHitMessage(msg string) {
 		l.t.Log(msg)
 	}
 }
+
+// editParams are the Params of a live run of an empty 16x16 board, for editWhilePaused to edit.
+func editParams() gol.Params {
+	return gol.Params{
+		Turns:       100000000,
+		Threads:     4,
+		ImageWidth:  16,
+		ImageHeight: 16,
+		Soup:        true,
+		Density:     0,
+		Live:        true,
+	}
+}
+
+// editWhilePaused runs p, calling edit once the run has been paused, and returns the events of the run.
+// The run is resumed after the edit and quit once it has completed a turn, which is when the edit has been applied.
+func editWhilePaused(t *testing.T, p gol.Params, edit func()) []gol.Event {
+	emptyOutFolder()
+	keyPresses := make(chan rune, 10)
+	events := make(chan gol.Event, 1000)
+	go gol.Run(p, events, keyPresses)
+
+	var all []gol.Event
+	started, pausedAt := false, -1
+	for event := range events {
+		all = append(all, event)
+		switch e := event.(type) {
+		case gol.StateChange:
+			switch {
+			case e.NewState == gol.Executing && !started:
+				started = true
+				keyPresses <- 'p'
+			case e.NewState == gol.Paused:
+				pausedAt = e.CompletedTurns
+				edit()
+				keyPresses <- 'p'
+			}
+		case gol.TurnComplete:
+			if pausedAt >= 0 && e.CompletedTurns > pausedAt {
+				pausedAt = -1
+				keyPresses <- 'q'
+			}
+		}
+	}
+	return all
+}
+
+// finalAlive returns the alive cells of the FinalTurnComplete among events.
+func finalAlive(events []gol.Event) []util.Cell {
+	for _, event := range events {
+		if e, ok := event.(gol.FinalTurnComplete); ok {
+			return e.Alive
+		}
+	}
+	return nil
+}
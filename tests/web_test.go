@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/web"
+)
+
+// handshake sends a WebSocket handshake from a page at origin to the viewer at url, returning its response.
+func handshake(t *testing.T, url, origin string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := "GET /ws HTTP/1.1\r\nHost: gol\r\nOrigin: " + origin + "\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+	in := bufio.NewReader(conn)
+	response, err := http.ReadResponse(in, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, in, response
+}
+
+// dialWebsocket opens a WebSocket to the viewer at url, checking the handshake.
+func dialWebsocket(t *testing.T, url string) (net.Conn, *bufio.Reader) {
+	conn, in, response := handshake(t, url, "http://gol")
+	assert(t, response.StatusCode == http.StatusSwitchingProtocols, "Expected 101 Switching Protocols, got %v", response.Status)
+	accept := response.Header.Get("Sec-WebSocket-Accept")
+	assert(t, accept == "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", "Unexpected Sec-WebSocket-Accept %q", accept)
+	return conn, in
+}
+
+// readFrame reads a single unmasked frame from the server.
+func readFrame(t *testing.T, in *bufio.Reader) []byte {
+	var header [2]byte
+	if _, err := io.ReadFull(in, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, header[0] == 0x82, "Expected a binary frame, got %#x", header[0])
+	length := int(header[1])
+	if length == 126 {
+		var extended [2]byte
+		if _, err := io.ReadFull(in, extended[:]); err != nil {
+			t.Fatal(err)
+		}
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(in, payload); err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+// TestWeb forwards the events of a glider through a web viewer and checks what a browser is sent,
+// and that keys pressed in the browser are passed on to gol.
+func TestWeb(t *testing.T) {
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16}
+	keyPresses := make(chan rune, 10)
+	viewer := web.NewViewer(p, keyPresses)
+	server := httptest.NewServer(viewer.Handler())
+	defer server.Close()
+
+	in := make(chan gol.Event)
+	out := make(chan gol.Event, 100)
+	go viewer.Forward(in, out)
+	glider := []util.Cell{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}
+	in <- gol.CellsFlipped{CompletedTurns: 0, Cells: glider}
+	in <- gol.StateChange{CompletedTurns: 0, NewState: gol.Executing}
+
+	conn, reader := dialWebsocket(t, server.URL)
+	defer conn.Close()
+
+	// A new browser is sent the whole world, then the state.
+	world := readFrame(t, reader)
+	assert(t, world[0] == 1, "Expected a world frame first, got type %v", world[0])
+	assert(t, binary.LittleEndian.Uint32(world[5:]) == 16 && binary.LittleEndian.Uint32(world[9:]) == 16,
+		"Expected a 16x16 world")
+	var alive []util.Cell
+	for y, row := range util.UnpackWorld(world[13:], 16, 16) {
+		for x, cell := range row {
+			if cell == 0xFF {
+				alive = append(alive, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	assertEqualBoard(t, alive, glider, p)
+	state := readFrame(t, reader)
+	assert(t, state[0] == 3 && gol.State(state[5]) == gol.Executing, "Expected the Executing state, got %v", state)
+
+	// Then the cells flipped each turn.
+	in <- gol.CellsFlipped{CompletedTurns: 1, Cells: []util.Cell{{X: 1, Y: 0}, {X: 0, Y: 1}}}
+	in <- gol.TurnComplete{CompletedTurns: 1}
+	flips := readFrame(t, reader)
+	assert(t, flips[0] == 2 && binary.LittleEndian.Uint32(flips[1:]) == 1, "Expected the flips of turn 1, got %v", flips)
+	assert(t, len(flips) == 13 && binary.LittleEndian.Uint32(flips[5:]) == 1 && binary.LittleEndian.Uint32(flips[9:]) == 16,
+		"Expected cells 1 and 16 to flip, got %v", flips[5:])
+
+	// Keys are sent as masked text frames.
+	mask := []byte{1, 2, 3, 4}
+	if _, err := conn.Write([]byte{0x81, 0x82, mask[0], mask[1], mask[2], mask[3], 'p' ^ mask[0], 'x' ^ mask[1]}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case key := <-keyPresses:
+		assert(t, key == 'p', "Expected p to be passed on, got %q", key)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the key pressed in the browser to be passed on")
+	}
+	assert(t, len(keyPresses) == 0, "Expected x not to be passed on")
+
+	close(in)
+	for range out {
+	}
+}
+
+// TestWebOrigin checks that a page served from elsewhere cannot open a WebSocket to the viewer and press its keys.
+func TestWebOrigin(t *testing.T) {
+	keyPresses := make(chan rune, 10)
+	viewer := web.NewViewer(gol.Params{ImageWidth: 16, ImageHeight: 16}, keyPresses)
+	server := httptest.NewServer(viewer.Handler())
+	defer server.Close()
+
+	conn, _, response := handshake(t, server.URL, "http://example.com")
+	defer conn.Close()
+	assert(t, response.StatusCode == http.StatusForbidden, "Expected 403 Forbidden for another origin, got %v", response.Status)
+}
@@ -15,17 +15,37 @@ type AvgTurns struct {
 	turns             [buffSize]int
 	durations         [buffSize]time.Duration
 	mutex             sync.Mutex
+	// startTurns and started are where the measurement began, which Average is measured from.
+	startTurns int
+	started    time.Time
 }
 
 func NewAvgTurns() *AvgTurns {
+	return NewAvgTurnsFrom(0)
+}
+
+// NewAvgTurnsFrom measures turns per second from a run that starts at completedTurns, as when resuming.
+func NewAvgTurnsFrom(completedTurns int) *AvgTurns {
 	return &AvgTurns{
 		count:             0,
-		lastCompleteTurns: 0,
+		lastCompleteTurns: completedTurns,
 		lastCalled:        time.Now(),
 		turns:             [buffSize]int{},
 		durations:         [buffSize]time.Duration{},
 		mutex:             sync.Mutex{},
+		startTurns:        completedTurns,
+		started:           time.Now(),
+	}
+}
+
+// Average returns the turns per second over the whole of the measurement up to completedTurns,
+// where TurnsPerSec averages only the last few calls.
+func (avg *AvgTurns) Average(completedTurns int) float64 {
+	elapsed := time.Since(avg.started).Seconds()
+	if elapsed <= 0 {
+		return 0
 	}
+	return float64(completedTurns-avg.startTurns) / elapsed
 }
 
 func (avg *AvgTurns) TurnsPerSec(completedTurns int) int {
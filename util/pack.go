@@ -0,0 +1,30 @@
+package util
+
+// PackWorld packs the cells of a width x height world into bits, one bit per cell in row-major order.
+func PackWorld(world [][]byte, width, height int) []byte {
+	packed := make([]byte, (width*height+7)/8)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if world[y][x] == 255 {
+				i := y*width + x
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+	}
+	return packed
+}
+
+// UnpackWorld is the inverse of PackWorld.
+func UnpackWorld(packed []byte, width, height int) [][]byte {
+	world := make([][]byte, height)
+	for y := range world {
+		world[y] = make([]byte, width)
+		for x := range world[y] {
+			i := y*width + x
+			if packed[i/8]&(1<<(i%8)) != 0 {
+				world[y][x] = 255
+			}
+		}
+	}
+	return world
+}
@@ -0,0 +1,19 @@
+package util
+
+import "fmt"
+
+const (
+	// MaxWorldSize is the largest width or height of a world read from a file.
+	MaxWorldSize = 1 << 16
+	// MaxWorldCells is the largest number of cells in a world read from a file, a byte each once loaded.
+	MaxWorldCells = 1 << 30
+)
+
+// CheckWorldSize returns an error if a world read from a file claims to be larger than MaxWorldSize or MaxWorldCells,
+// so that a malformed header can not make a reader allocate more memory than any real board needs.
+func CheckWorldSize(width, height int) error {
+	if width <= 0 || height <= 0 || width > MaxWorldSize || height > MaxWorldSize || width*height > MaxWorldCells {
+		return fmt.Errorf("invalid size %vx%v", width, height)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package util
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// snapshotMagic is the first line of every snapshot, followed by the version of the format.
+const snapshotMagic = "GOLSNAP 1"
+
+// Snapshot is a world together with everything needed to carry on running it.
+//
+// On disk a snapshot is a text header of "key value" lines ended by an empty line,
+// followed by the world bit-packed as by PackWorld and compressed with gzip.
+// The header holds the size of the world, the turn, the rule, the topology,
+// where the world came from if it was generated, and a CRC-32 checksum of the packed world.
+type Snapshot struct {
+	Width, Height int
+	Turn          int
+	Rule          string
+	Topology      string
+	Source        string
+	World         [][]byte
+}
+
+// NewSnapshot returns a snapshot of the world at turn, run as Conway's Life on a torus.
+func NewSnapshot(world [][]byte, turn int) Snapshot {
+	width := 0
+	if len(world) > 0 {
+		width = len(world[0])
+	}
+	return Snapshot{
+		Width:    width,
+		Height:   len(world),
+		Turn:     turn,
+		Rule:     "B3/S23",
+		Topology: "torus",
+		World:    world,
+	}
+}
+
+// WriteSnapshot writes the snapshot to w.
+func WriteSnapshot(w io.Writer, s Snapshot) error {
+	packed := PackWorld(s.World, s.Width, s.Height)
+
+	out := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(out, "%v\n", snapshotMagic)
+	_, _ = fmt.Fprintf(out, "width %v\nheight %v\nturn %v\n", s.Width, s.Height, s.Turn)
+	_, _ = fmt.Fprintf(out, "rule %v\ntopology %v\n", s.Rule, s.Topology)
+	if s.Source != "" {
+		_, _ = fmt.Fprintf(out, "source %v\n", s.Source)
+	}
+	_, _ = fmt.Fprintf(out, "crc32 %08x\n\n", crc32.ChecksumIEEE(packed))
+
+	body := gzip.NewWriter(out)
+	if _, err := body.Write(packed); err != nil {
+		return err
+	}
+	if err := body.Close(); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// readSnapshotHeader reads the header of a snapshot along with its checksum, leaving r at the start of the body.
+func readSnapshotHeader(r *bufio.Reader) (Snapshot, uint32, error) {
+	var s Snapshot
+	var checksum uint32
+	magic, err := r.ReadString('\n')
+	if err != nil || strings.TrimSpace(magic) != snapshotMagic {
+		return s, 0, errors.New("not a snapshot")
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return s, 0, fmt.Errorf("snapshot header: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return s, 0, fmt.Errorf("snapshot header: malformed line %q", line)
+		}
+		key, value := fields[0], strings.TrimSpace(fields[1])
+		switch key {
+		case "width":
+			s.Width, err = strconv.Atoi(value)
+		case "height":
+			s.Height, err = strconv.Atoi(value)
+		case "turn":
+			s.Turn, err = strconv.Atoi(value)
+		case "rule":
+			s.Rule = value
+		case "topology":
+			s.Topology = value
+		case "source":
+			s.Source = value
+		case "crc32":
+			var sum uint64
+			sum, err = strconv.ParseUint(value, 16, 32)
+			checksum = uint32(sum)
+		}
+		// Unknown keys are skipped, so that newer snapshots can still be read.
+		if err != nil {
+			return s, 0, fmt.Errorf("snapshot header: malformed %v %q", key, value)
+		}
+	}
+	if err := CheckWorldSize(s.Width, s.Height); err != nil {
+		return s, 0, fmt.Errorf("snapshot header: %v", err)
+	}
+	return s, checksum, nil
+}
+
+// ReadSnapshot reads a snapshot from r, checking the world against the checksum in the header.
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	in := bufio.NewReader(r)
+	s, checksum, err := readSnapshotHeader(in)
+	if err != nil {
+		return s, err
+	}
+
+	body, err := gzip.NewReader(in)
+	if err != nil {
+		return s, fmt.Errorf("snapshot body: %v", err)
+	}
+	packed := make([]byte, (s.Width*s.Height+7)/8)
+	if _, err := io.ReadFull(body, packed); err != nil {
+		return s, fmt.Errorf("snapshot body: %v", err)
+	}
+	if crc32.ChecksumIEEE(packed) != checksum {
+		return s, errors.New("snapshot body does not match its checksum")
+	}
+	s.World = UnpackWorld(packed, s.Width, s.Height)
+	return s, nil
+}
+
+// SaveSnapshot writes the snapshot to the file at path.
+func SaveSnapshot(path string, s Snapshot) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := WriteSnapshot(file, s); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// LoadSnapshot reads the snapshot in the file at path.
+func LoadSnapshot(path string) (Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer file.Close()
+	return ReadSnapshot(file)
+}
+
+// ReadSnapshotHeader reads only the header of the snapshot at path, leaving World empty.
+func ReadSnapshotHeader(path string) (Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer file.Close()
+	s, _, err := readSnapshotHeader(bufio.NewReader(file))
+	return s, err
+}
@@ -10,6 +10,56 @@ func VisualiseMatrix(given [][]uint8, width, height int) {
 	log.Print(matricesToString(given, nil, width, height))
 }
 
+// HalfBlocks draws the part of the world starting at (left, top) as rows of columns characters,
+// packing two cells above one another into each character with Unicode half blocks.
+// Cells beyond the edges of the world are drawn dead.
+func HalfBlocks(world [][]uint8, left, top, columns, rows int) []string {
+	blocks := [4]rune{' ', '▀', '▄', '█'}
+	lines := make([]string, rows)
+	line := make([]rune, columns)
+	for row := range lines {
+		for column := range line {
+			x, y := left+column, top+2*row
+			line[column] = blocks[aliveAt(world, x, y)|aliveAt(world, x, y+1)<<1]
+		}
+		lines[row] = string(line)
+	}
+	return lines
+}
+
+// brailleDots are the bits of the braille dots for the cells of a 2x4 block, by row and then column.
+var brailleDots = [4][2]int{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// Braille draws the part of the world starting at (left, top) as rows of columns characters,
+// packing a block of two by four cells into each character with Unicode braille patterns.
+// Cells beyond the edges of the world are drawn dead.
+func Braille(world [][]uint8, left, top, columns, rows int) []string {
+	lines := make([]string, rows)
+	line := make([]rune, columns)
+	for row := range lines {
+		for column := range line {
+			dots := 0
+			for dy, bits := range brailleDots {
+				for dx, bit := range bits {
+					if aliveAt(world, left+2*column+dx, top+4*row+dy) == 1 {
+						dots |= bit
+					}
+				}
+			}
+			line[column] = rune(0x2800 + dots)
+		}
+		lines[row] = string(line)
+	}
+	return lines
+}
+
+func aliveAt(world [][]uint8, x, y int) int {
+	if y < 0 || y >= len(world) || x < 0 || x >= len(world[y]) || world[y][x] != 0xFF {
+		return 0
+	}
+	return 1
+}
+
 func (c1 Cell) in(slice []Cell) bool {
 	for _, c2 := range slice {
 		if c1 == c2 {
@@ -0,0 +1,252 @@
+// Package web serves a live view of the board to browsers, with the page talking to the client over a WebSocket.
+package web
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+//go:embed index.html
+var page []byte
+
+// Frames sent to the browser start with their type, followed by little-endian uint32s.
+const (
+	// frameWorld holds the turn, width and height, then the world packed by util.PackWorld.
+	frameWorld = 1
+	// frameFlips holds the turn, then the index y*width+x of each cell flipped since the last frame.
+	frameFlips = 2
+	// frameState holds the turn, then the new state as a single byte.
+	frameState = 3
+)
+
+// frameInterval is the shortest time between frames sent to a browser. Changes in between are sent together.
+const frameInterval = time.Second / 30
+
+// keys are the key presses a browser may send on to gol.
+const keys = "psqk0123456789g,.+-"
+
+// Viewer keeps a copy of the board from the events it forwards and streams its changes to every connected browser.
+type Viewer struct {
+	p          gol.Params
+	keyPresses chan<- rune
+	done       chan struct{}
+
+	m     sync.Mutex
+	world [][]byte
+	turn  int
+	state gol.State
+	// flips are the cells flipped since the last TurnComplete, applied to world when it arrives.
+	flips   []uint32
+	clients map[*client]bool
+}
+
+// client is a connected browser and the changes waiting to be sent to it, guarded by the Viewer.
+type client struct {
+	ws     *websocket
+	notify chan struct{}
+	// gone is closed when the browser stops sending.
+	gone  chan struct{}
+	turn  int
+	flips []uint32
+	// keyframe is set when the whole world must be sent, either to a new browser or one that has fallen behind.
+	keyframe     bool
+	stateChanged bool
+}
+
+// NewViewer creates a viewer of a run with the given Params, sending the keys pressed in browsers to keyPresses.
+func NewViewer(p gol.Params, keyPresses chan<- rune) *Viewer {
+	world := make([][]byte, p.ImageHeight)
+	for i := range world {
+		world[i] = make([]byte, p.ImageWidth)
+	}
+	return &Viewer{
+		p:          p,
+		keyPresses: keyPresses,
+		done:       make(chan struct{}),
+		world:      world,
+		clients:    make(map[*client]bool),
+	}
+}
+
+// Handler serves the page at / and its WebSocket at /ws.
+func (v *Viewer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	})
+	mux.HandleFunc("/ws", v.serveWebsocket)
+	return mux
+}
+
+// Serve serves the viewer on addr until the server fails.
+func (v *Viewer) Serve(addr string) error {
+	return http.ListenAndServe(addr, v.Handler())
+}
+
+// Forward keeps the board up to date with every event received from in and passes it on to out.
+// Browsers are sent what is left and disconnected, and out is closed, once in is closed.
+func (v *Viewer) Forward(in <-chan gol.Event, out chan<- gol.Event) {
+	for event := range in {
+		v.apply(event)
+		out <- event
+	}
+	v.m.Lock()
+	v.publish(false)
+	close(v.done)
+	v.m.Unlock()
+	close(out)
+}
+
+func (v *Viewer) apply(event gol.Event) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	switch e := event.(type) {
+	case gol.CellFlipped:
+		v.flips = append(v.flips, uint32(e.Cell.Y*v.p.ImageWidth+e.Cell.X))
+	case gol.CellsFlipped:
+		for _, cell := range e.Cells {
+			v.flips = append(v.flips, uint32(cell.Y*v.p.ImageWidth+cell.X))
+		}
+	case gol.TurnComplete:
+		v.turn = e.CompletedTurns
+		v.publish(false)
+	case gol.StateChange:
+		v.turn = e.CompletedTurns
+		v.state = e.NewState
+		v.publish(true)
+	}
+}
+
+// publish applies the flips since the last turn to the world and hands them to every browser.
+// A browser with more flips waiting than would fit in a whole world is sent the world instead.
+func (v *Viewer) publish(stateChanged bool) {
+	width := v.p.ImageWidth
+	for _, i := range v.flips {
+		v.world[int(i)/width][int(i)%width] ^= 0xFF
+	}
+	for c := range v.clients {
+		c.turn = v.turn
+		c.stateChanged = c.stateChanged || stateChanged
+		if !c.keyframe {
+			c.flips = append(c.flips, v.flips...)
+			if len(c.flips) > width*v.p.ImageHeight/32 {
+				c.keyframe, c.flips = true, nil
+			}
+		}
+		select {
+		case c.notify <- struct{}{}:
+		default:
+		}
+	}
+	v.flips = v.flips[:0]
+}
+
+func (v *Viewer) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrade(w, r)
+	if err != nil {
+		log.Printf("[Web] %v %v: %v", util.Red("ERROR"), r.RemoteAddr, err)
+		return
+	}
+	log.Printf("[Web] Browser connected from %v", r.RemoteAddr)
+	c := &client{
+		ws:           ws,
+		notify:       make(chan struct{}, 1),
+		gone:         make(chan struct{}),
+		keyframe:     true,
+		stateChanged: true,
+	}
+	c.notify <- struct{}{}
+	v.m.Lock()
+	c.turn = v.turn
+	v.clients[c] = true
+	v.m.Unlock()
+
+	go v.readKeys(c)
+	v.stream(c)
+	v.m.Lock()
+	delete(v.clients, c)
+	v.m.Unlock()
+	ws.close()
+	log.Printf("[Web] Browser disconnected from %v", r.RemoteAddr)
+}
+
+// stream sends the changes waiting for a browser as they come in, until the run is over or the browser goes away.
+func (v *Viewer) stream(c *client) {
+	for {
+		finished := false
+		select {
+		case <-c.notify:
+		case <-c.gone:
+			return
+		case <-v.done:
+			finished = true
+		}
+		v.m.Lock()
+		frames := v.frames(c)
+		v.m.Unlock()
+		for _, frame := range frames {
+			if err := c.ws.write(opBinary, frame); err != nil {
+				return
+			}
+		}
+		if finished {
+			_ = c.ws.write(opClose, nil)
+			return
+		}
+		time.Sleep(frameInterval)
+	}
+}
+
+// frames encodes the changes waiting for a browser and clears them.
+func (v *Viewer) frames(c *client) [][]byte {
+	var frames [][]byte
+	if c.keyframe {
+		frame := []byte{frameWorld}
+		frame = binary.LittleEndian.AppendUint32(frame, uint32(v.turn))
+		frame = binary.LittleEndian.AppendUint32(frame, uint32(v.p.ImageWidth))
+		frame = binary.LittleEndian.AppendUint32(frame, uint32(v.p.ImageHeight))
+		frames = append(frames, append(frame, util.PackWorld(v.world, v.p.ImageWidth, v.p.ImageHeight)...))
+	} else if len(c.flips) > 0 || c.stateChanged {
+		frame := binary.LittleEndian.AppendUint32([]byte{frameFlips}, uint32(c.turn))
+		for _, i := range c.flips {
+			frame = binary.LittleEndian.AppendUint32(frame, i)
+		}
+		frames = append(frames, frame)
+	}
+	if c.stateChanged {
+		frame := binary.LittleEndian.AppendUint32([]byte{frameState}, uint32(v.turn))
+		frames = append(frames, append(frame, byte(v.state)))
+	}
+	c.keyframe, c.stateChanged, c.flips = false, false, nil
+	return frames
+}
+
+// readKeys sends the keys pressed in a browser on to gol.
+func (v *Viewer) readKeys(c *client) {
+	defer close(c.gone)
+	for {
+		message, err := c.ws.read()
+		if err != nil {
+			return
+		}
+		for _, key := range string(message) {
+			if !strings.ContainsRune(keys, key) {
+				continue
+			}
+			select {
+			case v.keyPresses <- key:
+			case <-v.done:
+				return
+			}
+		}
+	}
+}